@@ -0,0 +1,108 @@
+package rescheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of the circuit breaker of a Rescheduler
+type BreakerState int
+
+const (
+	// BreakerClosed allows passes to run
+	BreakerClosed BreakerState = iota
+	// BreakerOpen holds passes until the cooldown has elapsed
+	BreakerOpen
+	// BreakerHalfOpen allows a single trial pass to run, the breaker closes if
+	// it succeeds and opens again if it fails
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// WithCircuitBreaker opens the circuit breaker after threshold consecutive
+// passes return an error. While the breaker is open Run() requests are kept
+// pending but no passes are run until cooldown has elapsed, then a single
+// trial pass runs with all pending requests.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *config) {
+		c.breakerThreshold = threshold
+		c.breakerCooldown = cooldown
+	}
+}
+
+// breaker implements the circuit breaker state machine
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	lock     sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+// newBreaker returns nil if threshold disables the breaker
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// delay returns how long the next pass must wait, the breaker changes to
+// half-open once the cooldown has elapsed
+func (b *breaker) delay(now time.Time) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state != BreakerOpen {
+		return 0
+	}
+	if d := b.openedAt.Add(b.cooldown).Sub(now); d > 0 {
+		return d
+	}
+	b.state = BreakerHalfOpen
+	return 0
+}
+
+// record updates the breaker with the result of a pass
+func (b *breaker) record(err error, now time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if err == nil {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = now
+	}
+}
+
+func (b *breaker) getState() BreakerState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// BreakerState returns the state of the circuit breaker, BreakerClosed is
+// returned if the circuit breaker is disabled.
+func (r *Rescheduler) BreakerState() BreakerState {
+	b := r.settings.Load().breaker
+	if b == nil {
+		return BreakerClosed
+	}
+	return b.getState()
+}
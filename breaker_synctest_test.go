@@ -0,0 +1,95 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCircuitBreaker(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var failing atomic.Bool
+		failing.Store(true)
+		var lock sync.Mutex
+		var infos []PassInfo
+		passes := func() []PassInfo {
+			lock.Lock()
+			defer lock.Unlock()
+			return infos
+		}
+		r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+			lock.Lock()
+			infos = append(infos, info)
+			lock.Unlock()
+			if failing.Load() {
+				return errors.New("downstream is down")
+			}
+			return nil
+		}, WithCircuitBreaker(2, time.Minute))
+
+		// the breaker opens after two failures
+		r.Run()
+		r.Wait()
+		assert.Equal(t, BreakerClosed, r.BreakerState())
+		r.Run()
+		r.Wait()
+		assert.Equal(t, BreakerOpen, r.BreakerState())
+		assert.Len(t, passes(), 2)
+
+		// requests are kept pending while open
+		start := time.Now()
+		r.RunReason("a")
+		time.Sleep(30 * time.Second)
+		r.RunReason("b")
+		synctest.Wait()
+		assert.Len(t, passes(), 2)
+
+		// the trial pass fails and opens the breaker again
+		r.Wait()
+		assert.Equal(t, time.Minute, time.Since(start))
+		assert.Equal(t, BreakerOpen, r.BreakerState())
+		assert.Len(t, passes(), 3)
+		assert.Equal(t, 2, passes()[2].Coalesced)
+		assert.Equal(t, []string{"a", "b"}, passes()[2].Reasons)
+
+		// the next trial pass succeeds and closes the breaker
+		failing.Store(false)
+		start = time.Now()
+		r.Run()
+		r.Wait()
+		assert.Equal(t, time.Minute, time.Since(start))
+		assert.Equal(t, BreakerClosed, r.BreakerState())
+		assert.NoError(t, r.Err())
+
+		// passes run immediately once closed
+		start = time.Now()
+		r.Run()
+		r.Wait()
+		assert.Zero(t, time.Since(start))
+		assert.Len(t, passes(), 5)
+	})
+}
+
+func TestWithCircuitBreaker_Close(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+			return errors.New("fail")
+		}, WithCircuitBreaker(1, time.Hour))
+		r.Run()
+		r.Wait()
+
+		// closing releases a held pass without waiting for the cooldown
+		seq := r.RunSeq()
+		synctest.Wait()
+		assert.NoError(t, r.Close())
+		assert.ErrorIs(t, r.WaitFor(context.Background(), seq), ErrClosed)
+	})
+}
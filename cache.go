@@ -0,0 +1,136 @@
+package rescheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures the freshness of values in a Cache
+type CacheOptions struct {
+	// TTL is how long a loaded value is fresh, stale values trigger a
+	// background refresh
+	TTL time.Duration
+	// MaxStale is how long after TTL a stale value is served while refreshing,
+	// after this Get() waits for the refresh. Zero allows stale values to be
+	// served forever.
+	MaxStale time.Duration
+	// ErrorTTL is how long a load error is returned by Get() without loading
+	// again when there is no value to serve. Zero disables caching errors.
+	ErrorTTL time.Duration
+}
+
+// Cache serves a cached value and refreshes it in the background once it is
+// stale. Refreshes are run by a Rescheduler so concurrent Get() calls on a
+// stale value cause a single refresh.
+type Cache[T any] struct {
+	r    *Rescheduler
+	load func(ctx context.Context) (T, error)
+	opts CacheOptions
+
+	lock     sync.Mutex
+	value    T
+	hasValue bool
+	loadedAt time.Time
+	err      error
+	errAt    time.Time
+	// refreshing is true while a background refresh is requested or running
+	refreshing bool
+	// loadSeq is the request sequence of the load Get() is waiting for, zero
+	// if there is none
+	loadSeq uint64
+}
+
+// NewCache creates a Cache using load to fetch the value. The options are
+// passed to the underlying Rescheduler.
+func NewCache[T any](load func(ctx context.Context) (T, error), cacheOpts CacheOptions, opts ...Option) *Cache[T] {
+	c := &Cache[T]{load: load, opts: cacheOpts}
+	c.r = NewReschedulerFunc(c.refresh, opts...)
+	return c
+}
+
+// refresh is the call function of the underlying Rescheduler
+func (c *Cache[T]) refresh(ctx context.Context, info PassInfo) error {
+	start := time.Now()
+	value, err := c.load(ctx)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.refreshing = false
+	if info.Covers >= c.loadSeq {
+		c.loadSeq = 0
+	}
+	c.err = err
+	if err != nil {
+		c.errAt = time.Now()
+		return err
+	}
+	c.value = value
+	c.hasValue = true
+	c.loadedAt = start
+	return nil
+}
+
+// Get returns the cached value if it is fresh. A stale value is returned
+// immediately and triggers a background refresh. Get waits for a refresh when
+// there is no value yet or the value is older than TTL plus MaxStale, the
+// load error is returned if that refresh fails.
+func (c *Cache[T]) Get(ctx context.Context) (T, error) {
+	c.lock.Lock()
+	now := time.Now()
+	if c.hasValue {
+		value := c.value
+		age := now.Sub(c.loadedAt)
+		if age < c.opts.TTL {
+			c.lock.Unlock()
+			return value, nil
+		}
+		if c.opts.MaxStale == 0 || age < c.opts.TTL+c.opts.MaxStale {
+			refresh := !c.refreshing
+			c.refreshing = true
+			c.lock.Unlock()
+			if refresh {
+				c.r.RunReason("stale")
+			}
+			return value, nil
+		}
+	}
+	if c.err != nil && now.Sub(c.errAt) < c.opts.ErrorTTL {
+		err := c.err
+		c.lock.Unlock()
+		var zero T
+		return zero, err
+	}
+
+	// wait for a value, callers share the load requested by the first caller
+	// until it finishes
+	if c.loadSeq == 0 {
+		c.loadSeq = c.r.RunReasonSeq("load")
+	}
+	seq := c.loadSeq
+	c.lock.Unlock()
+
+	if err := c.r.WaitFor(ctx, seq); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err != nil {
+		var zero T
+		return zero, c.err
+	}
+	return c.value, nil
+}
+
+// Refresh triggers a background refresh of the value
+func (c *Cache[T]) Refresh() {
+	c.r.RunReason("refresh")
+}
+
+// Rescheduler returns the Rescheduler running the refreshes, it can be used to
+// wait for or close the Cache
+func (c *Cache[T]) Rescheduler() *Rescheduler {
+	return c.r
+}
@@ -0,0 +1,139 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var loads atomic.Int32
+		c := NewCache(func(ctx context.Context) (int32, error) {
+			time.Sleep(time.Second)
+			return loads.Add(1), nil
+		}, CacheOptions{TTL: time.Minute, MaxStale: time.Hour})
+		defer c.Rescheduler().Close()
+
+		// the first load blocks
+		start := time.Now()
+		v, err := c.Get(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), v)
+		assert.Equal(t, time.Second, time.Since(start))
+
+		// fresh values are served from the cache
+		time.Sleep(30 * time.Second)
+		v, _ = c.Get(context.Background())
+		assert.Equal(t, int32(1), v)
+		synctest.Wait()
+		assert.Equal(t, int32(1), loads.Load())
+
+		// stale values are served while a single refresh runs
+		time.Sleep(time.Minute)
+		start = time.Now()
+		for i := 0; i < 5; i++ {
+			v, _ = c.Get(context.Background())
+			assert.Equal(t, int32(1), v)
+		}
+		assert.Zero(t, time.Since(start))
+		c.Rescheduler().Wait()
+		assert.Equal(t, int32(2), loads.Load())
+		v, _ = c.Get(context.Background())
+		assert.Equal(t, int32(2), v)
+
+		// values older than max stale block for a refresh
+		time.Sleep(2 * time.Hour)
+		v, _ = c.Get(context.Background())
+		assert.Equal(t, int32(3), v)
+	})
+}
+
+func TestCache_Errors(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var loads atomic.Int32
+		var fail atomic.Bool
+		fail.Store(true)
+		c := NewCache(func(ctx context.Context) (string, error) {
+			loads.Add(1)
+			if fail.Load() {
+				return "", errors.New("fail")
+			}
+			return "ok", nil
+		}, CacheOptions{TTL: time.Minute, ErrorTTL: 10 * time.Second})
+		defer c.Rescheduler().Close()
+
+		_, err := c.Get(context.Background())
+		assert.EqualError(t, err, "fail")
+
+		// errors are cached for ErrorTTL
+		fail.Store(false)
+		_, err = c.Get(context.Background())
+		assert.EqualError(t, err, "fail")
+		assert.Equal(t, int32(1), loads.Load())
+
+		time.Sleep(10 * time.Second)
+		v, err := c.Get(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", v)
+
+		// a failed refresh keeps serving the stale value
+		fail.Store(true)
+		time.Sleep(time.Hour)
+		v, err = c.Get(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", v)
+		c.Rescheduler().Wait()
+		assert.Equal(t, int32(3), loads.Load())
+	})
+}
+
+func TestCache_Context(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		c := NewCache(func(ctx context.Context) (int, error) {
+			time.Sleep(time.Minute)
+			return 1, nil
+		}, CacheOptions{TTL: time.Minute})
+		defer c.Rescheduler().Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := c.Get(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestCache_ConcurrentColdGet(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var loads atomic.Int32
+		c := NewCache(func(ctx context.Context) (int32, error) {
+			time.Sleep(300 * time.Millisecond)
+			return loads.Add(1), nil
+		}, CacheOptions{TTL: time.Minute})
+		defer c.Rescheduler().Close()
+
+		// callers arriving during the first load share it
+		start := time.Now()
+		done := make(chan time.Duration, 10)
+		for range 10 {
+			go func() {
+				v, err := c.Get(context.Background())
+				assert.NoError(t, err)
+				assert.Equal(t, int32(1), v)
+				done <- time.Since(start)
+			}()
+			time.Sleep(10 * time.Millisecond)
+		}
+		for range 10 {
+			assert.Equal(t, 300*time.Millisecond, <-done)
+		}
+		assert.Equal(t, int32(1), loads.Load())
+	})
+}
@@ -0,0 +1,23 @@
+package rescheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Closed(t *testing.T) {
+	var loads atomic.Int32
+	c := NewCache(func(ctx context.Context) (int32, error) {
+		return loads.Add(1), nil
+	}, CacheOptions{TTL: time.Minute})
+	assert.NoError(t, c.Rescheduler().Close())
+
+	// there is no value and the load is never run
+	_, err := c.Get(context.Background())
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.Zero(t, loads.Load())
+}
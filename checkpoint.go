@@ -0,0 +1,221 @@
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// CheckpointStore persists the checkpoint of a Rescheduler
+type CheckpointStore interface {
+	// Load returns the saved checkpoint, nil is returned if there is none
+	Load() ([]byte, error)
+	// Save replaces the saved checkpoint
+	Save(data []byte) error
+	// Clear removes the saved checkpoint
+	Clear() error
+}
+
+// MemoryCheckpointStore is a CheckpointStore keeping the checkpoint in memory
+type MemoryCheckpointStore struct {
+	lock sync.Mutex
+	data []byte
+}
+
+// NewMemoryCheckpointStore creates an empty MemoryCheckpointStore
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{}
+}
+
+// Load returns a copy of the checkpoint, nil if none has been saved
+func (m *MemoryCheckpointStore) Load() ([]byte, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return slices.Clone(m.data), nil
+}
+
+// Save keeps a copy of data as the checkpoint
+func (m *MemoryCheckpointStore) Save(data []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.data = slices.Clone(data)
+	return nil
+}
+
+// Clear removes the checkpoint
+func (m *MemoryCheckpointStore) Clear() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.data = nil
+	return nil
+}
+
+// FileCheckpointStore is a CheckpointStore keeping the checkpoint in a file,
+// the file is replaced atomically when saving
+type FileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore creates a FileCheckpointStore using the file at path
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Load reads the checkpoint file, nil is returned if the file does not exist
+func (f *FileCheckpointStore) Load() ([]byte, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Save replaces the checkpoint file atomically by writing a temporary file
+// and renaming it over the checkpoint file
+func (f *FileCheckpointStore) Save(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// Clear removes the checkpoint file, a missing file is not an error
+func (f *FileCheckpointStore) Clear() error {
+	err := os.Remove(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// WithCheckpoints persists the checkpoints saved by passes in store, see
+// CheckpointFrom(). A checkpoint found in the store when the rescheduler
+// starts is offered to the first pass.
+func WithCheckpoints(store CheckpointStore) Option {
+	return func(c *config) {
+		c.checkpoints = store
+	}
+}
+
+// checkpointKey is the context key of the Checkpoint of a pass
+type checkpointKey struct{}
+
+// checkpointEntry is the last saved checkpoint, seq is the highest request
+// covered by the pass which saved it
+type checkpointEntry struct {
+	data []byte
+	seq  uint64
+}
+
+// Checkpoint saves the progress of a pass so a later pass can resume the work
+// if the pass is cancelled or fails. The checkpoint is offered to the next pass
+// unless a Run() request has been made since the pass which saved it started,
+// Resume() requests a pass without invalidating it. The checkpoint is cleared
+// when a pass succeeds.
+type Checkpoint struct {
+	r     *Rescheduler
+	store CheckpointStore
+	seq   uint64
+	data  []byte
+}
+
+// CheckpointFrom returns the Checkpoint of the pass context ctx. Outside a
+// pass or without WithCheckpoints() a nil Checkpoint is returned, Data()
+// returns nil and Save() does nothing for a nil Checkpoint.
+func CheckpointFrom(ctx context.Context) *Checkpoint {
+	c, _ := ctx.Value(checkpointKey{}).(*Checkpoint)
+	return c
+}
+
+// Data returns the checkpoint offered to this pass, nil if the pass must start
+// from the beginning
+func (c *Checkpoint) Data() []byte {
+	if c == nil {
+		return nil
+	}
+	return c.data
+}
+
+// Save persists data as the checkpoint of this pass
+func (c *Checkpoint) Save(data []byte) error {
+	if c == nil {
+		return nil
+	}
+	if err := c.store.Save(data); err != nil {
+		return err
+	}
+	c.r.checkpointLock.Lock()
+	c.r.checkpoint = &checkpointEntry{data: slices.Clone(data), seq: c.seq}
+	c.r.checkpointLock.Unlock()
+	return nil
+}
+
+// Resume is the same as Run() but the request does not invalidate the last
+// checkpoint, it can be used to retry a pass which failed
+func (r *Rescheduler) Resume() {
+	r.runSeq("resume", false)
+}
+
+// invalidateCheckpoint records that the request seq invalidates the checkpoint
+func (r *Rescheduler) invalidateCheckpoint(seq uint64) {
+	storeMax(&r.invalidated, seq)
+}
+
+// startCheckpoint returns the Checkpoint for the pass described by info.
+// Errors from the store are ignored so the pass starts from the beginning.
+func (r *Rescheduler) startCheckpoint(store CheckpointStore, info PassInfo) *Checkpoint {
+	r.checkpointLock.Lock()
+	defer r.checkpointLock.Unlock()
+
+	// a checkpoint left by a previous process is offered to the first pass
+	if !r.checkpointLoaded {
+		r.checkpointLoaded = true
+		if data, err := store.Load(); err == nil && data != nil {
+			r.checkpoint = &checkpointEntry{data: data, seq: math.MaxUint64}
+		}
+	}
+
+	c := &Checkpoint{r: r, store: store, seq: info.Covers}
+	switch {
+	case r.checkpoint == nil:
+	case r.checkpoint.seq == math.MaxUint64 || r.invalidated.Load() <= r.checkpoint.seq:
+		// requests served by this pass no longer invalidate the checkpoint
+		c.data = r.checkpoint.data
+		r.checkpoint.seq = info.Covers
+	default:
+		r.checkpoint = nil
+		_ = store.Clear()
+	}
+	return c
+}
+
+// finishCheckpoint clears the checkpoint after a successful pass
+func (r *Rescheduler) finishCheckpoint(store CheckpointStore, err error) {
+	if err != nil {
+		return
+	}
+	r.checkpointLock.Lock()
+	defer r.checkpointLock.Unlock()
+	if r.checkpoint != nil {
+		r.checkpoint = nil
+		_ = store.Clear()
+	}
+}
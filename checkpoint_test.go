@@ -0,0 +1,116 @@
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// checkpointRecorder is a call function saving a checkpoint then failing
+// while fail is set, it records the checkpoint offered to each pass
+type checkpointRecorder struct {
+	lock    sync.Mutex
+	fail    bool
+	offered []string
+}
+
+func (c *checkpointRecorder) call(ctx context.Context, info PassInfo) error {
+	cp := CheckpointFrom(ctx)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.offered = append(c.offered, string(cp.Data()))
+	if err := cp.Save([]byte("step")); err != nil {
+		return err
+	}
+	if c.fail {
+		return errors.New("fail")
+	}
+	return nil
+}
+
+func TestWithCheckpoints(t *testing.T) {
+	store := NewMemoryCheckpointStore()
+	c := &checkpointRecorder{fail: true}
+	r := NewReschedulerFunc(c.call, WithCheckpoints(store))
+	defer r.Close()
+
+	// a failed pass is resumed from its checkpoint
+	r.Run()
+	r.Wait()
+	r.Resume()
+	r.Wait()
+
+	// a new request invalidates the checkpoint
+	r.Run()
+	r.Wait()
+
+	// a successful pass clears the checkpoint
+	c.lock.Lock()
+	c.fail = false
+	c.lock.Unlock()
+	r.Resume()
+	r.Wait()
+	r.Resume()
+	r.Wait()
+
+	assert.Equal(t, []string{"", "step", "", "step", ""}, c.offered)
+	data, err := store.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestWithCheckpoints_Restart(t *testing.T) {
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint"))
+
+	// the pass is cancelled by Close after saving a checkpoint
+	saved := make(chan struct{})
+	r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+		assert.NoError(t, CheckpointFrom(ctx).Save([]byte("halfway")))
+		close(saved)
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithCheckpoints(store))
+	r.Run()
+	<-saved
+	assert.NoError(t, r.Close())
+
+	// the checkpoint is offered to the first pass of the next rescheduler
+	c := &checkpointRecorder{}
+	r = NewReschedulerFunc(c.call, WithCheckpoints(store))
+	defer r.Close()
+	r.Run()
+	r.Wait()
+	assert.Equal(t, []string{"halfway"}, c.offered)
+	data, err := store.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint"))
+	data, err := store.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+
+	assert.NoError(t, store.Save([]byte("one")))
+	assert.NoError(t, store.Save([]byte("two")))
+	data, err = store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("two"), data)
+
+	assert.NoError(t, store.Clear())
+	assert.NoError(t, store.Clear())
+	data, err = store.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestCheckpointFrom_OutsidePass(t *testing.T) {
+	cp := CheckpointFrom(context.Background())
+	assert.Nil(t, cp.Data())
+	assert.NoError(t, cp.Save([]byte("ignored")))
+}
@@ -0,0 +1,101 @@
+// Command reschedsim replays a trigger log written by a rescheduler.Recorder
+// against different scheduling modes to help tune their delays.
+//
+//	reschedsim -delay 500ms -modes immediate,debounce triggers.jsonl
+//
+// Pass durations are taken from the finished passes in the log unless set
+// with -duration.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mrmelon54/rescheduler"
+)
+
+func main() {
+	delay := flag.Duration("delay", time.Second, "delay used by the debounce, throttle and trailing modes")
+	duration := flag.Duration("duration", 0, "simulated pass duration, defaults to the durations in the log")
+	modeNames := flag.String("modes", "immediate,debounce,throttle,trailing", "comma separated modes to simulate")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [log.jsonl]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(flag.Arg(0), *delay, *duration, *modeNames, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "reschedsim:", err)
+		os.Exit(1)
+	}
+}
+
+func run(path string, delay, duration time.Duration, modeNames string, out io.Writer) error {
+	var selected []mode
+	for _, name := range strings.Split(modeNames, ",") {
+		m, err := findMode(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		selected = append(selected, m)
+	}
+
+	in := os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	records, err := rescheduler.ReadRecords(in)
+	if err != nil {
+		return err
+	}
+
+	var requests []time.Time
+	var durations []time.Duration
+	for _, r := range records {
+		switch r.Kind {
+		case "request":
+			requests = append(requests, r.Time)
+		case "pass-finish":
+			durations = append(durations, r.Duration)
+		}
+	}
+	if len(requests) == 0 {
+		return fmt.Errorf("no requests in log")
+	}
+	slices.SortFunc(requests, time.Time.Compare)
+
+	// recorded durations are reused in order when simulating more passes
+	passDuration := func(n int) time.Duration {
+		if duration > 0 || len(durations) == 0 {
+			return duration
+		}
+		return durations[n%len(durations)]
+	}
+
+	fmt.Fprintf(out, "%d requests over %s\n\n", len(requests), requests[len(requests)-1].Sub(requests[0]))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "mode\tpasses\tp50\tp90\tp99\tmax\tutilisation\t")
+	for _, m := range selected {
+		res := simulate(requests, m, delay, passDuration)
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%.1f%%\t\n", m.name, res.passes,
+			round(res.percentile(50)), round(res.percentile(90)), round(res.percentile(99)), round(res.percentile(100)),
+			res.utilisation*100)
+	}
+	return w.Flush()
+}
+
+// round keeps the latencies readable without hiding sub millisecond passes
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
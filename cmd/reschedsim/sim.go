@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// mode decides when a pass starts for the pending requests
+type mode struct {
+	name string
+	// start returns the earliest start of a pass serving pending, last is the
+	// start of the previous pass
+	start func(pending []time.Time, last time.Time, delay time.Duration) time.Time
+}
+
+var modes = []mode{
+	{"immediate", func(pending []time.Time, last time.Time, delay time.Duration) time.Time {
+		return pending[0]
+	}},
+	{"debounce", func(pending []time.Time, last time.Time, delay time.Duration) time.Time {
+		return pending[len(pending)-1].Add(delay)
+	}},
+	{"throttle", func(pending []time.Time, last time.Time, delay time.Duration) time.Time {
+		if last.IsZero() {
+			return pending[0]
+		}
+		return latest(pending[0], last.Add(delay))
+	}},
+	{"trailing", func(pending []time.Time, last time.Time, delay time.Duration) time.Time {
+		return pending[0].Add(delay)
+	}},
+}
+
+func findMode(name string) (mode, error) {
+	for _, m := range modes {
+		if m.name == name {
+			return m, nil
+		}
+	}
+	return mode{}, fmt.Errorf("unknown mode %q", name)
+}
+
+// result is the outcome of replaying requests against a mode
+type result struct {
+	passes      int
+	latencies   []time.Duration
+	busy        time.Duration
+	span        time.Duration
+	utilisation float64
+}
+
+// percentile returns the nearest rank percentile p of the latencies
+func (r result) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(p/100*float64(len(r.latencies))+0.5) - 1
+	return r.latencies[min(max(i, 0), len(r.latencies)-1)]
+}
+
+// simulate replays the sorted request times against m. Passes never overlap,
+// a pass serves every request made before it starts and the latency of a
+// request is the time until the pass serving it finishes. duration returns the
+// duration of the nth pass.
+func simulate(requests []time.Time, m mode, delay time.Duration, duration func(n int) time.Duration) result {
+	var res result
+	var pending []time.Time
+	var last, busyUntil time.Time
+	next := 0
+	for next < len(requests) || len(pending) > 0 {
+		if len(pending) == 0 {
+			pending = append(pending, requests[next])
+			next++
+		}
+
+		// requests made before the pass starts are served by it and can move
+		// the start later
+		start := latest(m.start(pending, last, delay), busyUntil)
+		for next < len(requests) && !requests[next].After(start) {
+			pending = append(pending, requests[next])
+			next++
+			start = latest(m.start(pending, last, delay), busyUntil)
+		}
+
+		d := duration(res.passes)
+		end := start.Add(d)
+		for _, req := range pending {
+			res.latencies = append(res.latencies, end.Sub(req))
+		}
+		res.passes++
+		res.busy += d
+		pending = pending[:0]
+		last, busyUntil = start, end
+	}
+
+	if len(requests) > 0 {
+		res.span = busyUntil.Sub(requests[0])
+	}
+	if res.span > 0 {
+		res.utilisation = float64(res.busy) / float64(res.span)
+	}
+	slices.Sort(res.latencies)
+	return res
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(offsets ...time.Duration) []time.Time {
+		times := make([]time.Time, len(offsets))
+		for i, o := range offsets {
+			times[i] = base.Add(o)
+		}
+		return times
+	}
+	// a burst of requests, a gap and a single request
+	requests := at(0, 100*time.Millisecond, 200*time.Millisecond, 300*time.Millisecond, 5*time.Second)
+	fixed := func(int) time.Duration { return time.Second }
+
+	for _, tt := range []struct {
+		mode    string
+		passes  int
+		p50     time.Duration
+		maximum time.Duration
+	}{
+		// the first request starts a pass and the rest of the burst reruns
+		{"immediate", 3, 1700 * time.Millisecond, 1900 * time.Millisecond},
+		{"debounce", 2, 1400 * time.Millisecond, 1600 * time.Millisecond},
+		{"throttle", 3, 1700 * time.Millisecond, 1900 * time.Millisecond},
+		{"trailing", 2, 1200 * time.Millisecond, 1300 * time.Millisecond},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			m, err := findMode(tt.mode)
+			assert.NoError(t, err)
+			res := simulate(requests, m, 300*time.Millisecond, fixed)
+			assert.Equal(t, tt.passes, res.passes)
+			assert.Equal(t, tt.p50, res.percentile(50))
+			assert.Equal(t, tt.maximum, res.percentile(100))
+			assert.Len(t, res.latencies, len(requests))
+		})
+	}
+
+	_, err := findMode("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package rescheduler
+
+import (
+	"context"
+	"sync"
+)
+
+// Computed holds the latest result of a compute function. The compute
+// function is run by a Rescheduler when Invalidate() is called, so multiple
+// invalidations while computing are merged into a single recomputation.
+//
+// Listeners are only notified when the new result differs from the previous
+// result under the equality function.
+type Computed[T any] struct {
+	r       *Rescheduler
+	compute func(ctx context.Context) (T, error)
+	equal   func(a, b T) bool
+
+	lock     sync.Mutex
+	value    T
+	hasValue bool
+	changed  chan struct{}
+	subs     []computedSub[T]
+	nextSub  int
+}
+
+type computedSub[T any] struct {
+	id int
+	fn func(old, new T)
+}
+
+// NewComputed creates a Computed running compute when invalidated. The equal
+// function reports whether two results are the same, if equal is nil every
+// result is treated as a change. The options are passed to the underlying
+// Rescheduler.
+func NewComputed[T any](compute func(ctx context.Context) (T, error), equal func(a, b T) bool, opts ...Option) *Computed[T] {
+	c := &Computed[T]{
+		compute: compute,
+		equal:   equal,
+		changed: make(chan struct{}),
+	}
+	c.r = NewReschedulerFunc(c.recompute, opts...)
+	return c
+}
+
+// recompute is the call function of the underlying Rescheduler
+func (c *Computed[T]) recompute(ctx context.Context, info PassInfo) error {
+	value, err := c.compute(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	// the first result is always a change, even if it equals the zero value
+	old := c.value
+	if c.hasValue && c.equal != nil && c.equal(old, value) {
+		c.lock.Unlock()
+		return nil
+	}
+	c.value = value
+	c.hasValue = true
+	close(c.changed)
+	c.changed = make(chan struct{})
+	subs := c.subs
+	c.lock.Unlock()
+
+	// subscribers are called outside the lock so they can call Get()
+	for _, sub := range subs {
+		sub.fn(old, value)
+	}
+	return nil
+}
+
+// Invalidate requests a recomputation of the value
+func (c *Computed[T]) Invalidate() {
+	c.r.Run()
+}
+
+// Get returns the latest computed value, the zero value is returned before
+// the first computation finishes
+func (c *Computed[T]) Get() T {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.value
+}
+
+// Lookup returns the latest computed value, ok is false before the first
+// computation finishes
+func (c *Computed[T]) Lookup() (value T, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.value, c.hasValue
+}
+
+// Changed returns a channel which is closed the next time the value changes
+func (c *Computed[T]) Changed() <-chan struct{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.changed
+}
+
+// Subscribe calls fn with the old and new value each time the value changes.
+// fn is called from the goroutine running the computation so it must not
+// wait for the Computed. The returned function removes the subscription.
+func (c *Computed[T]) Subscribe(fn func(old, new T)) (unsubscribe func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	id := c.nextSub
+	c.nextSub++
+
+	// copy on write so recompute() can use the slice outside the lock
+	subs := make([]computedSub[T], len(c.subs), len(c.subs)+1)
+	copy(subs, c.subs)
+	c.subs = append(subs, computedSub[T]{id: id, fn: fn})
+
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		subs := make([]computedSub[T], 0, len(c.subs))
+		for _, sub := range c.subs {
+			if sub.id != id {
+				subs = append(subs, sub)
+			}
+		}
+		c.subs = subs
+	}
+}
+
+// Rescheduler returns the Rescheduler running the computation, it can be used
+// to wait for or close the Computed
+func (c *Computed[T]) Rescheduler() *Rescheduler {
+	return c.r
+}
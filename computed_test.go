@@ -0,0 +1,119 @@
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputed(t *testing.T) {
+	var input atomic.Int32
+	var fail atomic.Bool
+	c := NewComputed(func(ctx context.Context) (int, error) {
+		if fail.Load() {
+			return 0, errors.New("fail")
+		}
+		return int(input.Load()) / 10, nil
+	}, func(a, b int) bool { return a == b })
+	defer c.Rescheduler().Close()
+
+	type change struct{ old, new int }
+	var changes []change
+	c.Subscribe(func(old, new int) {
+		changes = append(changes, change{old, new})
+	})
+	update := func(v int32) {
+		input.Store(v)
+		c.Invalidate()
+		c.Rescheduler().Wait()
+	}
+
+	changed := c.Changed()
+	update(15)
+	assert.Equal(t, 1, c.Get())
+	assert.Equal(t, []change{{0, 1}}, changes)
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Changed should be closed after a change")
+	}
+
+	// equal values do not notify
+	changed = c.Changed()
+	update(19)
+	assert.Equal(t, 1, c.Get())
+	assert.Len(t, changes, 1)
+	select {
+	case <-changed:
+		t.Fatal("Changed should not be closed without a change")
+	default:
+	}
+
+	// errors keep the previous value
+	fail.Store(true)
+	update(50)
+	assert.Equal(t, 1, c.Get())
+	assert.Error(t, c.Rescheduler().Err())
+	fail.Store(false)
+
+	update(42)
+	assert.Equal(t, 4, c.Get())
+	assert.Equal(t, []change{{0, 1}, {1, 4}}, changes)
+	<-changed
+}
+
+func TestComputed_Unsubscribe(t *testing.T) {
+	var input atomic.Int32
+	c := NewComputed(func(ctx context.Context) (int32, error) {
+		return input.Add(1), nil
+	}, nil)
+	defer c.Rescheduler().Close()
+
+	var a, b int
+	unsubscribeA := c.Subscribe(func(old, new int32) { a++ })
+	c.Subscribe(func(old, new int32) { b++ })
+
+	c.Invalidate()
+	c.Rescheduler().Wait()
+	unsubscribeA()
+	c.Invalidate()
+	c.Rescheduler().Wait()
+
+	assert.Equal(t, 1, a)
+	assert.Equal(t, 2, b)
+	assert.Equal(t, int32(2), c.Get())
+}
+
+func TestComputed_ZeroFirstValue(t *testing.T) {
+	c := NewComputed(func(ctx context.Context) (int, error) {
+		return 0, nil
+	}, func(a, b int) bool { return a == b })
+	defer c.Rescheduler().Close()
+
+	var changes int
+	c.Subscribe(func(old, new int) { changes++ })
+	changed := c.Changed()
+	_, ok := c.Lookup()
+	assert.False(t, ok)
+
+	// the first result is a change even though it equals the zero value
+	c.Invalidate()
+	c.Rescheduler().Wait()
+	value, ok := c.Lookup()
+	assert.True(t, ok)
+	assert.Zero(t, value)
+	assert.Equal(t, 1, changes)
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Changed should be closed after the first computation")
+	}
+
+	// later equal results do not notify
+	c.Invalidate()
+	c.Rescheduler().Wait()
+	assert.Equal(t, 1, changes)
+}
@@ -0,0 +1,72 @@
+package rescheduler
+
+import "time"
+
+// EventKind is the type of an Event
+type EventKind int
+
+const (
+	// EventPassStart is sent before the call function runs
+	EventPassStart EventKind = iota
+	// EventPassFinish is sent after the call function returns
+	EventPassFinish
+	// EventProgress is sent when the Progress of a pass is updated
+	EventProgress
+	// EventRequest is sent for each Run() request
+	EventRequest
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventPassStart:
+		return "pass-start"
+	case EventPassFinish:
+		return "pass-finish"
+	case EventProgress:
+		return "progress"
+	case EventRequest:
+		return "request"
+	}
+	return "unknown"
+}
+
+// Event describes activity of a Rescheduler
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	// Seq is the sequence number of the request, only set for EventRequest
+	Seq uint64
+	// Reason is the reason of the request, only set for EventRequest
+	Reason string
+	// Info describes the pass, not set for EventRequest
+	Info PassInfo
+	// Duration is the time taken by the pass, only set for EventPassFinish
+	Duration time.Duration
+	// Err is the error returned by the pass, only set for EventPassFinish
+	Err error
+	// Progress is the latest progress, only set for EventProgress
+	Progress ProgressSnapshot
+}
+
+// WithEvents calls fn for each event, when used more than once each function
+// is called in order. fn is called from the goroutine running the pass or
+// making the request so it must not wait for the rescheduler.
+func WithEvents(fn func(Event)) Option {
+	return func(c *config) {
+		if prev := c.events; prev != nil {
+			c.events = func(e Event) {
+				prev(e)
+				fn(e)
+			}
+			return
+		}
+		c.events = fn
+	}
+}
+
+// emit calls the events function of s if set
+func (s *settings) emit(e Event) {
+	if s.cfg.events != nil {
+		s.cfg.events(e)
+	}
+}
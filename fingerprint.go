@@ -0,0 +1,31 @@
+package rescheduler
+
+import "context"
+
+// FingerprintFunc returns a cheap fingerprint of the input to a pass, passes
+// are skipped when the fingerprint is unchanged
+type FingerprintFunc func(ctx context.Context) (string, error)
+
+// WithFingerprint computes the fingerprint before each pass and skips the
+// call function if it matches the fingerprint of the last successful pass.
+// Skipped passes still serve their requests and are counted in
+// Stats.Skipped. The pass runs if the fingerprint returns an error or the
+// settings were changed by SetCall() or Reconfigure().
+func WithFingerprint(fingerprint FingerprintFunc) Option {
+	return func(c *config) {
+		c.fingerprint = fingerprint
+	}
+}
+
+// fingerprint returns the fingerprint for the next pass, false is returned if
+// fingerprints are disabled or the fingerprint failed
+func (s *settings) fingerprint(ctx context.Context) (string, bool) {
+	if s.fingerprintFunc == nil {
+		return "", false
+	}
+	fp, err := s.fingerprintFunc(ctx)
+	if err != nil {
+		return "", false
+	}
+	return fp, true
+}
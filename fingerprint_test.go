@@ -0,0 +1,64 @@
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFingerprint(t *testing.T) {
+	var data, calls atomic.Int32
+	var fingerprintErr atomic.Bool
+	var callErr atomic.Bool
+	r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+		calls.Add(1)
+		if callErr.Load() {
+			return errors.New("fail")
+		}
+		return nil
+	}, WithFingerprint(func(ctx context.Context) (string, error) {
+		if fingerprintErr.Load() {
+			return "", errors.New("fingerprint failed")
+		}
+		return strconv.Itoa(int(data.Load())), nil
+	}))
+	run := func() {
+		r.Run()
+		r.Wait()
+	}
+
+	// the first pass always runs
+	run()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// unchanged input is skipped
+	run()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// changed input runs
+	data.Store(1)
+	run()
+	assert.Equal(t, int32(2), calls.Load())
+
+	// a failing fingerprint always runs
+	fingerprintErr.Store(true)
+	run()
+	assert.Equal(t, int32(3), calls.Load())
+	fingerprintErr.Store(false)
+
+	// a failed pass does not update the last fingerprint
+	data.Store(2)
+	callErr.Store(true)
+	run()
+	callErr.Store(false)
+	run()
+	assert.Equal(t, int32(5), calls.Load())
+	run()
+	assert.Equal(t, int32(5), calls.Load())
+
+	assert.Equal(t, Stats{Requests: 7, Passes: 5, Failures: 1, Skipped: 2}, r.Stats())
+}
@@ -0,0 +1,156 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+const (
+	fuzzOpRun = iota
+	fuzzOpWait
+	fuzzOpBurst
+	fuzzOpDuration
+	fuzzOpSleep
+	fuzzOpCount
+)
+
+// fuzzRun is a request made by the fuzz target, ticket is taken before the
+// request is made
+type fuzzRun struct {
+	ticket uint64
+	seq    uint64
+}
+
+// fuzzPass is a pass started by the rescheduler
+type fuzzPass struct {
+	ticket uint64
+	info   PassInfo
+}
+
+// FuzzRescheduler decodes the input into a sequence of operations against a
+// rescheduler inside a synctest bubble, so pass durations and sleeps use the
+// fake clock, then checks the coalescing invariants.
+func FuzzRescheduler(f *testing.F) {
+	f.Add([]byte{fuzzOpRun, fuzzOpRun, fuzzOpWait})
+	f.Add([]byte{fuzzOpDuration + 5*20, fuzzOpRun, fuzzOpBurst + 5*7, fuzzOpSleep + 5*10, fuzzOpRun, fuzzOpWait})
+	f.Add([]byte{fuzzOpBurst + 5*3, fuzzOpSleep + 5*1, fuzzOpBurst + 5*5, fuzzOpWait, fuzzOpRun})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		synctest.Test(t, func(t *testing.T) {
+			fuzzRescheduler(t, ops)
+		})
+	})
+}
+
+func fuzzRescheduler(t *testing.T, ops []byte) {
+	var ticket atomic.Uint64
+	var duration atomic.Int64
+	var active atomic.Int32
+	var completed atomic.Uint64
+
+	var lock sync.Mutex
+	var runs []fuzzRun
+	var passes []fuzzPass
+
+	r := NewReschedulerWithInfo(func(info PassInfo) {
+		if active.Add(1) != 1 {
+			t.Error("overlapping passes")
+		}
+		lock.Lock()
+		passes = append(passes, fuzzPass{ticket.Add(1), info})
+		lock.Unlock()
+
+		time.Sleep(time.Duration(duration.Load()))
+
+		completed.Store(info.Covers)
+		active.Add(-1)
+	})
+
+	run := func() {
+		n := ticket.Add(1)
+		seq := r.RunSeq()
+		lock.Lock()
+		runs = append(runs, fuzzRun{n, seq})
+		lock.Unlock()
+	}
+
+	for _, b := range ops {
+		arg := int(b / fuzzOpCount)
+		switch b % fuzzOpCount {
+		case fuzzOpRun:
+			run()
+		case fuzzOpWait:
+			// every request made before Wait() has been served
+			lock.Lock()
+			n := uint64(len(runs))
+			lock.Unlock()
+			r.Wait()
+			if got := completed.Load(); got < n {
+				t.Fatalf("Wait returned with %d requests completed, want %d", got, n)
+			}
+		case fuzzOpBurst:
+			var wg sync.WaitGroup
+			for i := 0; i < arg%8+1; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					run()
+				}()
+			}
+			wg.Wait()
+		case fuzzOpDuration:
+			duration.Store(int64(time.Duration(arg) * time.Millisecond))
+		case fuzzOpSleep:
+			time.Sleep(time.Duration(arg) * time.Millisecond)
+		}
+	}
+	r.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// each request is served by exactly one pass
+	total := 0
+	for i, p := range passes {
+		if p.info.Seq != uint64(i+1) {
+			t.Fatalf("pass %d has sequence %d", i+1, p.info.Seq)
+		}
+		if p.info.Coalesced < 1 {
+			t.Fatalf("pass %d serves no requests", p.info.Seq)
+		}
+		if i > 0 && p.info.Covers < passes[i-1].info.Covers {
+			t.Fatalf("pass %d covers %d which is lower than the previous pass", p.info.Seq, p.info.Covers)
+		}
+		total += p.info.Coalesced
+	}
+	if total != len(runs) {
+		t.Fatalf("passes served %d requests, want %d", total, len(runs))
+	}
+
+	// every request is followed by a pass which covers it
+	for _, run := range runs {
+		found := false
+		for _, p := range passes {
+			if p.ticket > run.ticket && p.info.Covers >= run.seq {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("request %d has no following pass", run.seq)
+		}
+	}
+
+	// the rescheduler is idle so waiting returns immediately
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.WaitContext(ctx); err != nil {
+		t.Fatalf("WaitContext on idle rescheduler: %v", err)
+	}
+}
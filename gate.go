@@ -0,0 +1,145 @@
+package rescheduler
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Gate holds pending passes while the system is under stress
+type Gate interface {
+	// Allow returns true if a pass may start now
+	Allow() bool
+}
+
+// GateFunc is an adapter to use a function as a Gate
+type GateFunc func() bool
+
+// Allow calls f()
+func (f GateFunc) Allow() bool { return f() }
+
+// WithGate holds pending passes until gate allows them. The gate is checked
+// every poll interval and the pass starts anyway once it has been held for
+// maxDeferral, a zero maxDeferral holds the pass until the gate allows it.
+func WithGate(gate Gate, poll, maxDeferral time.Duration) Option {
+	return func(c *config) {
+		if poll <= 0 {
+			poll = time.Second
+		}
+		c.gate = gate
+		c.gatePoll = poll
+		c.gateMaxDeferral = maxDeferral
+	}
+}
+
+// gateDelay returns how long to wait before checking the gate again, since
+// is set to when the gate first held the pass
+func (cfg *config) gateDelay(now time.Time, since *time.Time) time.Duration {
+	if cfg.gate == nil || cfg.gate.Allow() {
+		return 0
+	}
+	if since.IsZero() {
+		*since = now
+	}
+	d := cfg.gatePoll
+	if cfg.gateMaxDeferral > 0 {
+		remaining := cfg.gateMaxDeferral - now.Sub(*since)
+		if remaining <= 0 {
+			return 0
+		}
+		d = min(d, remaining)
+	}
+	return d
+}
+
+// PressureThresholds are the limits used by PressureGate, zero limits are not
+// checked
+type PressureThresholds struct {
+	// Load1 is the limit of the one minute load average
+	Load1 float64
+	// CPU, Memory and IO are the limits of the ten second average of the
+	// "some" pressure stall information as a percentage
+	CPU, Memory, IO float64
+}
+
+// PressureGate is a Gate using the Linux load average and pressure stall
+// information, metrics which cannot be read are ignored
+type PressureGate struct {
+	// FS is the proc filesystem
+	FS         fs.FS
+	Thresholds PressureThresholds
+}
+
+// NewPressureGate creates a PressureGate reading from /proc
+func NewPressureGate(thresholds PressureThresholds) *PressureGate {
+	return &PressureGate{FS: os.DirFS("/proc"), Thresholds: thresholds}
+}
+
+// Allow returns true if all metrics are below the thresholds
+func (g *PressureGate) Allow() bool {
+	if g.Thresholds.Load1 > 0 {
+		if load, err := readLoad(g.FS); err == nil && load >= g.Thresholds.Load1 {
+			return false
+		}
+	}
+	for _, p := range []struct {
+		name  string
+		limit float64
+	}{
+		{"cpu", g.Thresholds.CPU},
+		{"memory", g.Thresholds.Memory},
+		{"io", g.Thresholds.IO},
+	} {
+		if p.limit <= 0 {
+			continue
+		}
+		if avg, err := readPressure(g.FS, p.name); err == nil && avg >= p.limit {
+			return false
+		}
+	}
+	return true
+}
+
+// readLoad returns the one minute load average from loadavg
+func readLoad(fsys fs.FS) (float64, error) {
+	b, err := fs.ReadFile(fsys, "loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("rescheduler: empty loadavg")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// readPressure returns the ten second average of the "some" line from the
+// pressure stall information of a resource
+func readPressure(fsys fs.FS, resource string) (float64, error) {
+	f, err := fsys.Open("pressure/" + resource)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "some" {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if v, ok := strings.CutPrefix(field, "avg10="); ok {
+				return strconv.ParseFloat(v, 64)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("rescheduler: missing pressure for %s", resource)
+}
@@ -0,0 +1,47 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithGate(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var allow atomic.Bool
+		var passes atomic.Int32
+		r := NewRescheduler(func() {
+			passes.Add(1)
+		}, WithGate(GateFunc(allow.Load), time.Second, time.Minute))
+
+		// the pass is held until the next poll after the gate allows it
+		start := time.Now()
+		r.Run()
+		time.Sleep(9500 * time.Millisecond)
+		assert.Zero(t, passes.Load())
+		allow.Store(true)
+		r.Wait()
+		assert.Equal(t, 10*time.Second, time.Since(start))
+		assert.Equal(t, int32(1), passes.Load())
+
+		// the pass runs anyway after the maximum deferral
+		allow.Store(false)
+		start = time.Now()
+		r.Run()
+		r.Wait()
+		assert.Equal(t, time.Minute, time.Since(start))
+		assert.Equal(t, int32(2), passes.Load())
+
+		// the gate is ignored by RunNow
+		start = time.Now()
+		r.RunNow("emergency")
+		r.Wait()
+		assert.Zero(t, time.Since(start))
+		assert.Equal(t, int32(3), passes.Load())
+	})
+}
@@ -0,0 +1,33 @@
+package rescheduler
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPressureGate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"loadavg":         {Data: []byte("2.50 1.20 0.80 3/467 12345\n")},
+		"pressure/cpu":    {Data: []byte("some avg10=12.50 avg60=4.00 avg300=1.00 total=123\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")},
+		"pressure/memory": {Data: []byte("some avg10=0.00 avg60=0.00 avg300=0.00 total=0\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")},
+	}
+	for _, tt := range []struct {
+		name       string
+		thresholds PressureThresholds
+		want       bool
+	}{
+		{"no thresholds", PressureThresholds{}, true},
+		{"load below", PressureThresholds{Load1: 4}, true},
+		{"load above", PressureThresholds{Load1: 2}, false},
+		{"cpu above", PressureThresholds{CPU: 10}, false},
+		{"memory below", PressureThresholds{Memory: 10}, true},
+		{"missing io", PressureThresholds{IO: 10}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &PressureGate{FS: fsys, Thresholds: tt.thresholds}
+			assert.Equal(t, tt.want, g.Allow())
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package rescheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the number of passes kept by History() unless changed
+// with WithHistory()
+const DefaultHistorySize = 16
+
+// PassRecord describes a finished pass in History()
+type PassRecord struct {
+	// Seq is the sequence number of the pass, zero for skipped passes
+	Seq       uint64        `json:"seq"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	Duration  time.Duration `json:"duration"`
+	Coalesced int           `json:"coalesced"`
+	Reasons   []string      `json:"reasons,omitempty"`
+	// Error is the error returned by the pass
+	Error string `json:"error,omitempty"`
+	// Rerun is true if the pass ran due to requests made during the previous
+	// pass
+	Rerun bool `json:"rerun"`
+	// Skipped is true if the pass was skipped due to an unchanged fingerprint
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// WithHistory keeps the last n passes in History(), zero disables the history
+func WithHistory(n int) Option {
+	return func(c *config) {
+		c.historySize = max(n, 0)
+	}
+}
+
+// passHistory is a ring buffer of the latest passes
+type passHistory struct {
+	lock    sync.Mutex
+	records []PassRecord
+	next    int
+}
+
+// add adds rec to the history, the history is resized to size first
+func (h *passHistory) add(rec PassRecord, size int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if size != cap(h.records) {
+		records := h.listLocked()
+		records = records[max(len(records)-size, 0):]
+		h.records = append(make([]PassRecord, 0, size), records...)
+		h.next = len(h.records) % max(size, 1)
+	}
+	if size == 0 {
+		return
+	}
+	if len(h.records) < size {
+		h.records = append(h.records, rec)
+	} else {
+		h.records[h.next] = rec
+	}
+	h.next = (h.next + 1) % size
+}
+
+// listLocked returns the records from oldest to newest
+func (h *passHistory) listLocked() []PassRecord {
+	records := make([]PassRecord, 0, len(h.records))
+	if len(h.records) < cap(h.records) {
+		return append(records, h.records...)
+	}
+	records = append(records, h.records[h.next:]...)
+	return append(records, h.records[:h.next]...)
+}
+
+// History returns the latest passes from oldest to newest, see WithHistory()
+func (r *Rescheduler) History() []PassRecord {
+	r.history.lock.Lock()
+	defer r.history.lock.Unlock()
+	return r.history.listLocked()
+}
+
+// recordPass adds a finished pass to the history
+func (r *Rescheduler) recordPass(s *settings, info PassInfo, start, end time.Time, err error, rerun, skipped bool) {
+	rec := PassRecord{
+		Seq:       info.Seq,
+		Start:     start,
+		End:       end,
+		Duration:  end.Sub(start),
+		Coalesced: info.Coalesced,
+		Reasons:   info.Reasons,
+		Rerun:     rerun,
+		Skipped:   skipped,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	r.history.add(rec, s.cfg.historySize)
+}
@@ -0,0 +1,70 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRescheduler_History(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+			time.Sleep(time.Second)
+			if info.Seq == 2 {
+				return errors.New("fail")
+			}
+			return nil
+		}, WithHistory(3))
+
+		start := time.Now()
+		r.RunReason("first")
+		synctest.Wait()
+		r.RunReason("second")
+		r.RunReason("second")
+		r.Wait()
+
+		assert.Equal(t, []PassRecord{
+			{Seq: 1, Start: start, End: start.Add(time.Second), Duration: time.Second, Coalesced: 1, Reasons: []string{"first"}},
+			{Seq: 2, Start: start.Add(time.Second), End: start.Add(2 * time.Second), Duration: time.Second, Coalesced: 2, Reasons: []string{"second"}, Error: "fail", Rerun: true},
+		}, r.History())
+
+		// only the latest passes are kept
+		for range 3 {
+			r.Run()
+			r.Wait()
+		}
+		history := r.History()
+		if assert.Len(t, history, 3) {
+			assert.Equal(t, uint64(3), history[0].Seq)
+			assert.Equal(t, uint64(5), history[2].Seq)
+			assert.False(t, history[2].Rerun)
+		}
+
+		// shrinking the history keeps the latest passes
+		r.Reconfigure(WithHistory(1))
+		r.Run()
+		r.Wait()
+		history = r.History()
+		if assert.Len(t, history, 1) {
+			assert.Equal(t, uint64(6), history[0].Seq)
+		}
+
+		// the history is included in the admin handler
+		rec := httptest.NewRecorder()
+		r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		var state State
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+		assert.Equal(t, uint64(6), state.History[0].Seq)
+
+		assert.NoError(t, r.Close())
+	})
+}
@@ -0,0 +1,15 @@
+package rescheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHistory_Disabled(t *testing.T) {
+	r := NewRescheduler(func() {}, WithHistory(0))
+	defer r.Close()
+	r.Run()
+	r.Wait()
+	assert.Empty(t, r.History())
+}
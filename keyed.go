@@ -0,0 +1,150 @@
+package rescheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// KeyErrors is returned by a Keyed pass when keys fail, it maps each failed
+// key to its error
+type KeyErrors[K comparable] map[K]error
+
+func (e KeyErrors[K]) Error() string {
+	msgs := make([]string, 0, len(e))
+	for key, err := range e {
+		msgs = append(msgs, fmt.Sprintf("%v: %v", key, err))
+	}
+	slices.Sort(msgs)
+	return fmt.Sprintf("rescheduler: %d keys failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failed keys
+func (e KeyErrors[K]) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Keyed collects a set of dirty keys and drains them in parallel. Each pass
+// takes the whole dirty set and splits it across the workers, a key is only
+// in the set once so it is never processed concurrently with itself.
+//
+// Failed keys are marked dirty again without requesting a pass, so they are
+// retried by the next pass started by Mark() or by calling Run() on the
+// Rescheduler.
+type Keyed[K comparable] struct {
+	r       *Rescheduler
+	fn      func(ctx context.Context, key K) error
+	workers int
+
+	lock  sync.Mutex
+	dirty map[K]struct{}
+}
+
+// NewKeyed creates a Keyed calling fn for each dirty key using up to workers
+// goroutines. The options are passed to the underlying Rescheduler.
+func NewKeyed[K comparable](fn func(ctx context.Context, key K) error, workers int, opts ...Option) *Keyed[K] {
+	k := &Keyed[K]{
+		fn:      fn,
+		workers: max(workers, 1),
+		dirty:   make(map[K]struct{}),
+	}
+	k.r = NewReschedulerFunc(k.drain, opts...)
+	return k
+}
+
+// Mark adds the keys to the dirty set and requests a pass
+func (k *Keyed[K]) Mark(keys ...K) {
+	k.lock.Lock()
+	for _, key := range keys {
+		k.dirty[key] = struct{}{}
+	}
+	k.lock.Unlock()
+	k.r.Run()
+}
+
+// take returns the dirty keys and resets the dirty set
+func (k *Keyed[K]) take() []K {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	keys := make([]K, 0, len(k.dirty))
+	for key := range k.dirty {
+		keys = append(keys, key)
+	}
+	clear(k.dirty)
+	return keys
+}
+
+// requeue adds the keys back to the dirty set
+func (k *Keyed[K]) requeue(keys []K) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	for _, key := range keys {
+		k.dirty[key] = struct{}{}
+	}
+}
+
+// drain is the call function of the underlying Rescheduler
+func (k *Keyed[K]) drain(ctx context.Context, info PassInfo) error {
+	keys := k.take()
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var lock sync.Mutex
+	errs := make(KeyErrors[K])
+	var skipped []K
+
+	ch := make(chan K)
+	var wg sync.WaitGroup
+	for range min(k.workers, len(keys)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range ch {
+				if err := k.fn(ctx, key); err != nil {
+					lock.Lock()
+					errs[key] = err
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+
+	// keys which are not started before the pass context is cancelled are
+	// kept dirty
+send:
+	for i, key := range keys {
+		select {
+		case ch <- key:
+		case <-ctx.Done():
+			skipped = keys[i:]
+			break send
+		}
+	}
+	close(ch)
+	wg.Wait()
+
+	k.requeue(skipped)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	failed := make([]K, 0, len(errs))
+	for key := range errs {
+		failed = append(failed, key)
+	}
+	k.requeue(failed)
+	return errs
+}
+
+// Rescheduler returns the Rescheduler running the passes, it can be used to
+// wait for or close the Keyed
+func (k *Keyed[K]) Rescheduler() *Rescheduler {
+	return k.r
+}
@@ -0,0 +1,104 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyed(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var lock sync.Mutex
+		active := make(map[int]bool)
+		processed := make(map[int]int)
+		failing := map[int]bool{3: true}
+		concurrent, maxConcurrent := 0, 0
+
+		k := NewKeyed(func(ctx context.Context, key int) error {
+			lock.Lock()
+			if active[key] {
+				t.Errorf("key %d processed concurrently", key)
+			}
+			active[key] = true
+			concurrent++
+			maxConcurrent = max(maxConcurrent, concurrent)
+			lock.Unlock()
+
+			time.Sleep(time.Second)
+
+			lock.Lock()
+			defer lock.Unlock()
+			active[key] = false
+			concurrent--
+			if failing[key] {
+				return errors.New("fail")
+			}
+			processed[key]++
+			return nil
+		}, 4)
+		r := k.Rescheduler()
+
+		// the first pass drains all keys across the workers
+		start := time.Now()
+		k.Mark(1, 2, 3, 4, 5, 6, 7, 8)
+		synctest.Wait()
+		k.Mark(1, 2)
+
+		// the failed key is retried with the marked keys
+		time.Sleep(2500 * time.Millisecond)
+		var keyErrs KeyErrors[int]
+		if assert.ErrorAs(t, r.Err(), &keyErrs) {
+			assert.Equal(t, []int{3}, mapKeys(keyErrs))
+		}
+		lock.Lock()
+		failing[3] = false
+		lock.Unlock()
+		r.Wait()
+
+		assert.NoError(t, r.Err())
+		assert.Equal(t, 4, maxConcurrent)
+		assert.Equal(t, map[int]int{1: 2, 2: 2, 3: 1, 4: 1, 5: 1, 6: 1, 7: 1, 8: 1}, processed)
+		assert.Equal(t, 3*time.Second, time.Since(start))
+	})
+}
+
+func TestKeyed_AlwaysFailing(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		k := NewKeyed(func(ctx context.Context, key int) error {
+			return errors.New("fail")
+		}, 2)
+		r := k.Rescheduler()
+
+		// a failing key does not request another pass by itself
+		k.Mark(1)
+		r.Wait()
+		time.Sleep(time.Minute)
+		synctest.Wait()
+		assert.Equal(t, uint64(1), r.Stats().Passes)
+
+		// the failed key is kept dirty for the next pass
+		k.Mark(2)
+		r.Wait()
+		var keyErrs KeyErrors[int]
+		if assert.ErrorAs(t, r.Err(), &keyErrs) {
+			assert.ElementsMatch(t, []int{1, 2}, mapKeys(keyErrs))
+		}
+		assert.Equal(t, uint64(2), r.Stats().Passes)
+		assert.NoError(t, r.Close())
+	})
+}
+
+func mapKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
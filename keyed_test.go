@@ -0,0 +1,15 @@
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyErrors(t *testing.T) {
+	err := KeyErrors[string]{"b": errors.New("fail b"), "a": context.Canceled}
+	assert.EqualError(t, err, "rescheduler: 2 keys failed: a: context canceled; b: fail b")
+	assert.ErrorIs(t, err, context.Canceled)
+}
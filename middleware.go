@@ -0,0 +1,107 @@
+package rescheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps the call function of each pass. Middleware can run code
+// before and after the pass, change the pass context or change the error
+// returned by the pass.
+type Middleware func(next CallFunc) CallFunc
+
+// WithMiddleware adds middleware around each pass. The first middleware is
+// the outermost and runs first.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *config) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// chainMiddleware wraps call with mw, the first middleware is the outermost
+func chainMiddleware(call CallFunc, mw []Middleware) CallFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		call = mw[i](call)
+	}
+	return call
+}
+
+// PanicError is returned by passes which panicked when using the Recover
+// middleware.
+type PanicError struct {
+	// Value is the value passed to panic()
+	Value any
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("rescheduler: pass panicked: %v", p.Value)
+}
+
+// Recover returns middleware which recovers panics in the pass and returns
+// them as a *PanicError.
+func Recover() Middleware {
+	return func(next CallFunc) CallFunc {
+		return func(ctx context.Context, info PassInfo) (err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					err = &PanicError{Value: v, Stack: debug.Stack()}
+				}
+			}()
+			return next(ctx, info)
+		}
+	}
+}
+
+// Timeout returns middleware which cancels the pass context after d.
+func Timeout(d time.Duration) Middleware {
+	return func(next CallFunc) CallFunc {
+		return func(ctx context.Context, info PassInfo) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, info)
+		}
+	}
+}
+
+// Logging returns middleware which logs the start and end of each pass to
+// logger. Failed passes are logged at error level.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next CallFunc) CallFunc {
+		return func(ctx context.Context, info PassInfo) error {
+			logger.DebugContext(ctx, "rescheduler pass started", "pass", info.Seq, "coalesced", info.Coalesced, "reasons", info.Reasons)
+			start := time.Now()
+			err := next(ctx, info)
+			if err != nil {
+				logger.ErrorContext(ctx, "rescheduler pass failed", "pass", info.Seq, "duration", time.Since(start), "err", err)
+			} else {
+				logger.InfoContext(ctx, "rescheduler pass finished", "pass", info.Seq, "duration", time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// MetricsRecorder receives the result of each pass from the Metrics
+// middleware.
+type MetricsRecorder interface {
+	// ObservePass is called after each pass with the pass duration and the
+	// error returned by the pass
+	ObservePass(info PassInfo, duration time.Duration, err error)
+}
+
+// Metrics returns middleware which reports each pass to m.
+func Metrics(m MetricsRecorder) Middleware {
+	return func(next CallFunc) CallFunc {
+		return func(ctx context.Context, info PassInfo) error {
+			start := time.Now()
+			err := next(ctx, info)
+			m.ObservePass(info, time.Since(start), err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithMiddleware(Timeout(time.Minute)))
+		start := time.Now()
+		r.Run()
+		r.Wait()
+		assert.ErrorIs(t, r.Err(), context.DeadlineExceeded)
+		assert.Equal(t, time.Minute, time.Since(start))
+	})
+}
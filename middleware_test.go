@@ -0,0 +1,97 @@
+package rescheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMiddleware_Order(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next CallFunc) CallFunc {
+			return func(ctx context.Context, info PassInfo) error {
+				order = append(order, name+" before")
+				err := next(ctx, info)
+				order = append(order, name+" after")
+				return err
+			}
+		}
+	}
+
+	r := NewRescheduler(func() {
+		order = append(order, "call")
+	}, WithMiddleware(mw("a"), mw("b")), WithMiddleware(mw("c")))
+	r.Run()
+	r.Wait()
+	assert.Equal(t, []string{"a before", "b before", "c before", "call", "c after", "b after", "a after"}, order)
+}
+
+func TestRecover(t *testing.T) {
+	r := NewRescheduler(func() {
+		panic("oops")
+	}, WithMiddleware(Recover()))
+	r.Run()
+	r.Wait()
+
+	var panicErr *PanicError
+	assert.ErrorAs(t, r.Err(), &panicErr)
+	assert.Equal(t, "oops", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+}
+
+func TestLogging(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	fail := errors.New("fail")
+	r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+		if info.Seq == 2 {
+			return fail
+		}
+		return nil
+	}, WithMiddleware(Logging(logger)))
+
+	r.RunReason("first")
+	r.Wait()
+	r.Run()
+	r.Wait()
+
+	out := buf.String()
+	assert.Contains(t, out, `msg="rescheduler pass started" pass=1 coalesced=1 reasons=[first]`)
+	assert.Contains(t, out, `msg="rescheduler pass finished" pass=1`)
+	assert.Contains(t, out, `level=ERROR msg="rescheduler pass failed" pass=2`)
+	assert.Contains(t, out, `err=fail`)
+}
+
+type testMetrics struct {
+	passes []uint64
+	errs   []error
+}
+
+func (m *testMetrics) ObservePass(info PassInfo, duration time.Duration, err error) {
+	m.passes = append(m.passes, info.Seq)
+	m.errs = append(m.errs, err)
+}
+
+func TestMetrics(t *testing.T) {
+	m := &testMetrics{}
+	fail := errors.New("fail")
+	r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+		if info.Seq == 2 {
+			return fail
+		}
+		return nil
+	}, WithMiddleware(Metrics(m)))
+
+	r.Run()
+	r.Wait()
+	r.Run()
+	r.Wait()
+	assert.Equal(t, []uint64{1, 2}, m.passes)
+	assert.Equal(t, []error{nil, fail}, m.errs)
+}
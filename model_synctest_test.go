@@ -0,0 +1,348 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+)
+
+type modelEventKind int
+
+const (
+	eventRun modelEventKind = iota
+	eventPassStart
+	eventPassEnd
+	eventWaitReturn
+	eventWaitClosed
+	eventClose
+)
+
+func (k modelEventKind) String() string {
+	switch k {
+	case eventRun:
+		return "run"
+	case eventPassStart:
+		return "pass-start"
+	case eventPassEnd:
+		return "pass-end"
+	case eventWaitReturn:
+		return "wait-return"
+	case eventWaitClosed:
+		return "wait-closed"
+	case eventClose:
+		return "close"
+	}
+	return "unknown"
+}
+
+// modelEvent is an entry in the event log of the harness, seq is the request
+// sequence for runs and waits and the covered sequence for passes
+type modelEvent struct {
+	kind modelEventKind
+	seq  uint64
+}
+
+func (e modelEvent) String() string {
+	return fmt.Sprintf("%s(%d)", e.kind, e.seq)
+}
+
+// model is the reference model of the rescheduler
+type model struct {
+	seq       uint64
+	running   bool
+	covers    uint64
+	rerun     bool
+	completed uint64
+	closed    bool
+	waiters   map[int]uint64
+}
+
+func (m *model) run() uint64 {
+	m.seq++
+	// requests after close are never served
+	if m.closed {
+		return m.seq
+	}
+	if m.running {
+		m.rerun = true
+	} else {
+		m.running = true
+		m.covers = m.seq
+	}
+	return m.seq
+}
+
+func (m *model) finish() {
+	m.completed = m.covers
+	if m.rerun {
+		m.rerun = false
+		m.covers = m.seq
+	} else {
+		m.running = false
+	}
+	for id, seq := range m.waiters {
+		if m.completed >= seq {
+			delete(m.waiters, id)
+		}
+	}
+}
+
+// close drops the running pass and pending requests and releases all waiters
+func (m *model) close() {
+	m.closed = true
+	m.running = false
+	m.rerun = false
+	clear(m.waiters)
+}
+
+// modelHarness drives a real rescheduler with passes which block until the
+// harness releases them
+type modelHarness struct {
+	t       *testing.T
+	r       *Rescheduler
+	release chan struct{}
+	active  atomic.Int32
+
+	// waiters is the number of waiting goroutines started
+	waiters int
+
+	lock     sync.Mutex
+	events   []modelEvent
+	returned map[int]bool
+}
+
+func newModelHarness(t *testing.T) *modelHarness {
+	h := &modelHarness{
+		t:        t,
+		release:  make(chan struct{}),
+		returned: make(map[int]bool),
+	}
+	h.r = NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+		if h.active.Add(1) != 1 {
+			t.Error("overlapping passes")
+		}
+		h.record(modelEvent{eventPassStart, info.Covers})
+		select {
+		case <-h.release:
+		case <-ctx.Done():
+		}
+		h.record(modelEvent{eventPassEnd, info.Covers})
+		h.active.Add(-1)
+		return nil
+	})
+	return h
+}
+
+func (h *modelHarness) record(e modelEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.events = append(h.events, e)
+}
+
+// lastPassStart returns the covered sequence of the last pass started
+func (h *modelHarness) lastPassStart() uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for i := len(h.events) - 1; i >= 0; i-- {
+		if h.events[i].kind == eventPassStart {
+			return h.events[i].seq
+		}
+	}
+	return 0
+}
+
+// compare checks the harness state matches the model once all goroutines are
+// blocked
+func (h *modelHarness) compare(m *model) {
+	synctest.Wait()
+	if running := h.active.Load() == 1; running != m.running {
+		h.t.Fatalf("running: got %v, want %v", running, m.running)
+	}
+	if m.running {
+		if covers := h.lastPassStart(); covers != m.covers {
+			h.t.Fatalf("pass covers: got %d, want %d", covers, m.covers)
+		}
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for id := 0; id < h.waiters; id++ {
+		_, waiting := m.waiters[id]
+		if h.returned[id] == waiting {
+			h.t.Fatalf("waiter %d: returned %v, model waiting %v", id, h.returned[id], waiting)
+		}
+	}
+}
+
+// checkInvariants checks the event log of a finished harness
+func (h *modelHarness) checkInvariants() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// closedIndex is the index of the close event or -1 if not closed
+	closedIndex := -1
+	for i, e := range h.events {
+		if e.kind == eventClose {
+			closedIndex = i
+			break
+		}
+	}
+
+	// passes never overlap
+	inPass := false
+	for i, e := range h.events {
+		switch e.kind {
+		case eventPassStart:
+			if inPass {
+				h.t.Fatalf("event %d: pass started during another pass: %v", i, h.events)
+			}
+			inPass = true
+		case eventPassEnd:
+			inPass = false
+		}
+	}
+
+	for i, e := range h.events {
+		switch e.kind {
+		case eventRun:
+			// every run is followed by a pass starting after it unless the
+			// rescheduler was closed after the run
+			if passEndAfter(h.events, i, e.seq) == -1 && closedIndex < i {
+				h.t.Fatalf("event %d: run has no following pass: %v", i, h.events)
+			}
+		case eventWaitReturn:
+			// waits never return before the pass started after the run ends
+			run := runIndex(h.events, e.seq)
+			end := passEndAfter(h.events, run, e.seq)
+			if end == -1 || end > i {
+				h.t.Fatalf("event %d: wait returned before the pass ended: %v", i, h.events)
+			}
+		}
+	}
+}
+
+func runIndex(events []modelEvent, seq uint64) int {
+	for i, e := range events {
+		if e.kind == eventRun && e.seq == seq {
+			return i
+		}
+	}
+	return -1
+}
+
+// passEndAfter returns the index of the end of the first pass starting after
+// index i which covers seq
+func passEndAfter(events []modelEvent, i int, seq uint64) int {
+	started := false
+	for j := i + 1; j < len(events); j++ {
+		switch events[j].kind {
+		case eventPassStart:
+			started = events[j].seq >= seq
+		case eventPassEnd:
+			if started {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// TestRescheduler_Model drives random interleavings of Run, WaitFor, Close and
+// pass completion inside a synctest bubble and compares the rescheduler with the
+// reference model after every step
+func TestRescheduler_Model(t *testing.T) {
+	const seeds = 200
+	const steps = 60
+	for seed := uint64(0); seed < seeds; seed++ {
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				testModel(t, rand.New(rand.NewPCG(seed, seed)), steps)
+			})
+		})
+	}
+}
+
+func testModel(t *testing.T, rnd *rand.Rand, steps int) {
+	h := newModelHarness(t)
+	m := &model{waiters: make(map[int]uint64)}
+
+	for i := 0; i < steps; i++ {
+		switch op := rnd.IntN(10); {
+		case op < 4:
+			// record the run before calling Run() as the pass can start
+			// before RunSeq() returns
+			if !m.closed {
+				h.record(modelEvent{eventRun, m.seq + 1})
+			}
+			if seq, want := h.r.RunSeq(), m.run(); seq != want {
+				t.Fatalf("run sequence: got %d, want %d", seq, want)
+			}
+		case op < 6:
+			if m.seq == 0 {
+				continue
+			}
+			// wait for a random earlier request
+			id := h.waiters
+			h.waiters++
+			seq := rnd.Uint64N(m.seq) + 1
+			if m.completed < seq && !m.closed {
+				m.waiters[id] = seq
+			}
+			wantClosed := m.completed < seq && m.closed
+			go func() {
+				kind := eventWaitReturn
+				err := h.r.WaitFor(context.Background(), seq)
+				switch {
+				case errors.Is(err, ErrClosed):
+					kind = eventWaitClosed
+				case err != nil:
+					t.Error(err)
+				case wantClosed:
+					t.Error("wait after close returned without error")
+				}
+				h.lock.Lock()
+				h.events = append(h.events, modelEvent{kind, seq})
+				h.returned[id] = true
+				h.lock.Unlock()
+			}()
+		case op < 7:
+			// closing is rare so most runs test the coalescing
+			if rnd.IntN(8) != 0 {
+				continue
+			}
+			h.record(modelEvent{eventClose, m.seq})
+			if err := h.r.Close(); err != nil {
+				t.Fatal(err)
+			}
+			m.close()
+		default:
+			if !m.running {
+				continue
+			}
+			h.release <- struct{}{}
+			m.finish()
+		}
+		h.compare(m)
+	}
+
+	// finish the remaining passes
+	for m.running {
+		h.release <- struct{}{}
+		m.finish()
+		h.compare(m)
+	}
+	if len(m.waiters) != 0 {
+		t.Fatalf("model waiters left: %v", m.waiters)
+	}
+	synctest.Wait()
+	if len(h.returned) != h.waiters {
+		t.Fatalf("waiters returned: got %d, want %d", len(h.returned), h.waiters)
+	}
+	h.checkInvariants()
+}
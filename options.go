@@ -0,0 +1,123 @@
+package rescheduler
+
+import "time"
+
+// Option configures a Rescheduler when it is created or reconfigured
+type Option func(c *config)
+
+// config holds the options of a Rescheduler
+type config struct {
+	middleware []Middleware
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+
+	fingerprint FingerprintFunc
+
+	resources *Resources
+	exclusive []string
+	shared    []string
+
+	allowed  []Window
+	blackout []Window
+
+	gate            Gate
+	gatePoll        time.Duration
+	gateMaxDeferral time.Duration
+
+	weightThreshold float64
+	weightMaxDelay  time.Duration
+
+	events func(Event)
+
+	checkpoints CheckpointStore
+
+	historySize int
+}
+
+// newConfig applies opts to the default config
+func newConfig(opts []Option) *config {
+	c := &config{historySize: DefaultHistorySize}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// settings holds the call function and options used by each pass, it is
+// replaced as a whole by SetCall() and Reconfigure() so a pass never sees a
+// mix of old and new settings
+type settings struct {
+	// base is the call function without middleware
+	base CallFunc
+	cfg  *config
+
+	call CallFunc
+	// breaker holds passes after repeated failures, nil if disabled
+	breaker *breaker
+	// fingerprintFunc returns the fingerprint of the pass input, nil if
+	// disabled
+	fingerprintFunc FingerprintFunc
+	// claim holds the resources required by each pass, nil if none are
+	// required
+	claim *resourceClaim
+}
+
+// newSettings creates the settings for base and cfg, the circuit breaker of
+// prev is kept if its options are unchanged
+func newSettings(base CallFunc, cfg *config, prev *settings) *settings {
+	s := &settings{
+		base:            base,
+		cfg:             cfg,
+		call:            chainMiddleware(base, cfg.middleware),
+		fingerprintFunc: cfg.fingerprint,
+		claim:           newResourceClaim(cfg.resources, cfg.exclusive, cfg.shared),
+	}
+	if prev != nil && prev.cfg.breakerThreshold == cfg.breakerThreshold && prev.cfg.breakerCooldown == cfg.breakerCooldown {
+		s.breaker = prev.breaker
+	} else {
+		s.breaker = newBreaker(cfg.breakerThreshold, cfg.breakerCooldown)
+	}
+	return s
+}
+
+// holdDelay returns how long the next pass must wait before starting, the
+// time windows are ignored if override is true
+func (s *settings) holdDelay(now time.Time, override bool) time.Duration {
+	if s.breaker != nil {
+		if d := s.breaker.delay(now); d > 0 {
+			return d
+		}
+	}
+	if !override {
+		return s.cfg.windowDelay(now)
+	}
+	return 0
+}
+
+// SetCall replaces the call function from the next pass, the running pass
+// continues with the previous call function. Pending requests, the rerun flag
+// and waiting goroutines are kept.
+func (r *Rescheduler) SetCall(call CallFunc) {
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+	prev := r.settings.Load()
+	r.settings.Store(newSettings(call, prev.cfg, prev))
+	r.resetFingerprint.Store(true)
+	r.notify()
+}
+
+// Reconfigure replaces all options from the next pass, options set when the
+// rescheduler was created or by a previous call to Reconfigure are dropped.
+// The running pass continues with the previous options and a held pass is
+// checked again with the new options. Pending requests, the rerun flag and
+// waiting goroutines are kept. The circuit breaker state is kept if its
+// options are unchanged.
+func (r *Rescheduler) Reconfigure(opts ...Option) {
+	r.settingsLock.Lock()
+	defer r.settingsLock.Unlock()
+	prev := r.settings.Load()
+	r.settings.Store(newSettings(prev.base, newConfig(opts), prev))
+	r.resetFingerprint.Store(true)
+	r.notify()
+}
@@ -0,0 +1,84 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRescheduler_SetCall(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var lock sync.Mutex
+		var calls []string
+		record := func(name string) CallFunc {
+			return func(ctx context.Context, info PassInfo) error {
+				time.Sleep(time.Second)
+				lock.Lock()
+				calls = append(calls, name)
+				lock.Unlock()
+				return nil
+			}
+		}
+
+		r := NewReschedulerFunc(record("old"))
+		r.Run()
+		synctest.Wait()
+
+		// the running pass keeps the old call function and the pending rerun
+		// uses the new one
+		seq := r.RunSeq()
+		r.SetCall(record("new"))
+		assert.NoError(t, r.WaitFor(context.Background(), seq))
+		assert.Equal(t, []string{"old", "new"}, calls)
+		assert.Equal(t, uint64(2), r.Stats().Passes)
+	})
+}
+
+func TestRescheduler_Reconfigure(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var fail bool
+		var infos []PassInfo
+		r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+			infos = append(infos, info)
+			if fail {
+				return errors.New("fail")
+			}
+			return nil
+		}, WithCircuitBreaker(1, time.Minute))
+
+		fail = true
+		r.Run()
+		r.Wait()
+		assert.Equal(t, BreakerOpen, r.BreakerState())
+
+		// the breaker state is kept when its options are unchanged
+		var order []string
+		r.Reconfigure(WithCircuitBreaker(1, time.Minute), WithMiddleware(func(next CallFunc) CallFunc {
+			return func(ctx context.Context, info PassInfo) error {
+				order = append(order, "middleware")
+				return next(ctx, info)
+			}
+		}))
+		assert.Equal(t, BreakerOpen, r.BreakerState())
+
+		// removing the breaker releases the held pass
+		fail = false
+		start := time.Now()
+		r.RunReason("held")
+		synctest.Wait()
+		r.Reconfigure()
+		r.Wait()
+		assert.Less(t, time.Since(start), time.Minute)
+		assert.Equal(t, BreakerClosed, r.BreakerState())
+		assert.Empty(t, order)
+		assert.Len(t, infos, 2)
+		assert.Equal(t, []string{"held"}, infos[1].Reasons)
+	})
+}
@@ -0,0 +1,107 @@
+package rescheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// progressKey is the context key of the Progress of a pass
+type progressKey struct{}
+
+// Progress reports how far along a pass is, the latest values are visible in
+// State() and the admin handler and each update is sent as an EventProgress
+// event. Progress is safe for concurrent use.
+type Progress struct {
+	info   PassInfo
+	start  time.Time
+	events func(Event)
+
+	lock  sync.Mutex
+	stage string
+	done  int64
+	total int64
+}
+
+// ProgressSnapshot is the state of a Progress at a point in time
+type ProgressSnapshot struct {
+	// Stage is the name of the current stage of the pass
+	Stage string `json:"stage,omitempty"`
+	// Done is the number of units of work completed
+	Done int64 `json:"done"`
+	// Total is the total number of units of work, zero if unknown
+	Total int64 `json:"total"`
+	// Started is the time the pass started
+	Started time.Time `json:"started"`
+	// Rate is the number of units completed per second since the pass started
+	Rate float64 `json:"rate"`
+	// ETA is the estimated time until the pass finishes, zero if unknown
+	ETA time.Duration `json:"eta"`
+}
+
+// ProgressFrom returns the Progress of the pass context ctx. Outside a pass a
+// nil Progress is returned, all methods of a nil Progress do nothing.
+func ProgressFrom(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	return p
+}
+
+func newProgress(info PassInfo, start time.Time, events func(Event)) *Progress {
+	return &Progress{info: info, start: start, events: events}
+}
+
+// SetTotal sets the total number of units of work in the pass
+func (p *Progress) SetTotal(total int64) {
+	p.update(func() { p.total = total })
+}
+
+// Add adds n units of completed work
+func (p *Progress) Add(n int64) {
+	p.update(func() { p.done += n })
+}
+
+// SetStage sets the name of the current stage of the pass
+func (p *Progress) SetStage(stage string) {
+	p.update(func() { p.stage = stage })
+}
+
+// update applies fn under the lock and sends the progress event
+func (p *Progress) update(fn func()) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	fn()
+	snapshot := p.snapshotLocked(time.Now())
+	p.lock.Unlock()
+
+	if p.events != nil {
+		p.events(Event{Kind: EventProgress, Time: time.Now(), Info: p.info, Progress: snapshot})
+	}
+}
+
+// Snapshot returns the current progress
+func (p *Progress) Snapshot() ProgressSnapshot {
+	if p == nil {
+		return ProgressSnapshot{}
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.snapshotLocked(time.Now())
+}
+
+func (p *Progress) snapshotLocked(now time.Time) ProgressSnapshot {
+	s := ProgressSnapshot{
+		Stage:   p.stage,
+		Done:    p.done,
+		Total:   p.total,
+		Started: p.start,
+	}
+	if elapsed := now.Sub(p.start); elapsed > 0 && p.done > 0 {
+		s.Rate = float64(p.done) / elapsed.Seconds()
+		if remaining := p.total - p.done; remaining > 0 {
+			s.ETA = time.Duration(float64(remaining) / s.Rate * float64(time.Second))
+		}
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var lock sync.Mutex
+		var events []Event
+		release := make(chan struct{})
+		r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+			p := ProgressFrom(ctx)
+			p.SetStage("copy")
+			p.SetTotal(100)
+			time.Sleep(10 * time.Second)
+			p.Add(25)
+			<-release
+			return nil
+		}, WithEvents(func(e Event) {
+			lock.Lock()
+			events = append(events, e)
+			lock.Unlock()
+		}))
+
+		start := time.Now()
+		r.Run()
+		time.Sleep(10 * time.Second)
+		synctest.Wait()
+
+		// a quarter done after 10s leaves 30s at the same rate
+		state := r.State()
+		assert.True(t, state.Running)
+		if assert.NotNil(t, state.Progress) {
+			assert.Equal(t, ProgressSnapshot{
+				Stage:   "copy",
+				Done:    25,
+				Total:   100,
+				Started: start,
+				Rate:    2.5,
+				ETA:     30 * time.Second,
+			}, *state.Progress)
+		}
+
+		release <- struct{}{}
+		r.Wait()
+		assert.Nil(t, r.State().Progress)
+
+		lock.Lock()
+		defer lock.Unlock()
+		kinds := make([]EventKind, len(events))
+		for i, e := range events {
+			kinds[i] = e.Kind
+		}
+		assert.Equal(t, []EventKind{EventRequest, EventPassStart, EventProgress, EventProgress, EventProgress, EventPassFinish}, kinds)
+		assert.Equal(t, int64(25), events[4].Progress.Done)
+		assert.Equal(t, 10*time.Second, events[5].Duration)
+	})
+}
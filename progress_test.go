@@ -0,0 +1,40 @@
+package rescheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressFrom_OutsidePass(t *testing.T) {
+	p := ProgressFrom(context.Background())
+	assert.Nil(t, p)
+	p.SetTotal(10)
+	p.Add(1)
+	p.SetStage("ignored")
+	assert.Zero(t, p.Snapshot())
+}
+
+func TestRescheduler_Handler(t *testing.T) {
+	r := NewRescheduler(func() {})
+	defer r.Close()
+	r.Run()
+	r.Wait()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var state State
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Equal(t, "closed", state.Breaker)
+	assert.Equal(t, uint64(1), state.Stats.Passes)
+
+	rec = httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
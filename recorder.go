@@ -0,0 +1,116 @@
+package rescheduler
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Record is a single line written by a Recorder
+type Record struct {
+	Time time.Time `json:"time"`
+	// Kind is "request", "pass-start" or "pass-finish"
+	Kind string `json:"kind"`
+	// Seq is the request sequence for requests and the pass sequence for
+	// passes
+	Seq    uint64 `json:"seq"`
+	Reason string `json:"reason,omitempty"`
+	// Coalesced and Covers describe the requests served by a pass
+	Coalesced int    `json:"coalesced,omitempty"`
+	Covers    uint64 `json:"covers,omitempty"`
+	// Duration and Error are only set for pass-finish
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Recorder writes every request and the start and finish of every pass as
+// JSON Lines, see WithRecorder() and ReadRecords()
+type Recorder struct {
+	lock   sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+	err    error
+}
+
+// NewRecorder creates a Recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// OpenRecorder creates a Recorder appending to the file at path
+func OpenRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	rec := NewRecorder(f)
+	rec.closer = f
+	return rec, nil
+}
+
+// WithRecorder writes the events of the rescheduler to rec
+func WithRecorder(rec *Recorder) Option {
+	return WithEvents(rec.Record)
+}
+
+// Record writes the event e, progress events are ignored. Write errors are
+// available from Err().
+func (rec *Recorder) Record(e Event) {
+	r := Record{Time: e.Time, Kind: e.Kind.String()}
+	switch e.Kind {
+	case EventRequest:
+		r.Seq = e.Seq
+		r.Reason = e.Reason
+	case EventPassStart, EventPassFinish:
+		r.Seq = e.Info.Seq
+		r.Coalesced = e.Info.Coalesced
+		r.Covers = e.Info.Covers
+		r.Duration = e.Duration
+		if e.Err != nil {
+			r.Error = e.Err.Error()
+		}
+	default:
+		return
+	}
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	if err := rec.enc.Encode(r); err != nil && rec.err == nil {
+		rec.err = err
+	}
+}
+
+// Err returns the first error from writing a record
+func (rec *Recorder) Err() error {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	return rec.err
+}
+
+// Close closes the file opened by OpenRecorder()
+func (rec *Recorder) Close() error {
+	if rec.closer == nil {
+		return nil
+	}
+	return rec.closer.Close()
+}
+
+// ReadRecords reads the records written by a Recorder
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,48 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var buf bytes.Buffer
+		rec := NewRecorder(&buf)
+		r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+			ProgressFrom(ctx).Add(1)
+			time.Sleep(time.Second)
+			return errors.New("fail")
+		}, WithRecorder(rec))
+
+		start := time.Now()
+		r.RunReason("first")
+		r.Wait()
+		assert.NoError(t, rec.Err())
+
+		records, err := ReadRecords(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, []Record{
+			{Time: start, Kind: "request", Seq: 1, Reason: "first"},
+			{Time: start, Kind: "pass-start", Seq: 1, Coalesced: 1, Covers: 1},
+			{Time: start.Add(time.Second), Kind: "pass-finish", Seq: 1, Coalesced: 1, Covers: 1, Duration: time.Second, Error: "fail"},
+		}, normalizeRecords(records))
+	})
+}
+
+// normalizeRecords strips the location from the record times so they compare
+// equal to times from the fake clock
+func normalizeRecords(records []Record) []Record {
+	for i := range records {
+		records[i].Time = records[i].Time.Local()
+	}
+	return records
+}
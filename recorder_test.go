@@ -0,0 +1,27 @@
+package rescheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.jsonl")
+	for range 2 {
+		rec, err := OpenRecorder(path)
+		assert.NoError(t, err)
+		rec.Record(Event{Kind: EventRequest, Seq: 1})
+		assert.NoError(t, rec.Close())
+	}
+
+	// the file is appended to
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	records, err := ReadRecords(f)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+}
@@ -10,86 +10,638 @@
 //
 // Once the first runner finishes it will run once more due to a rerun being
 // requested.
+//
+// A Rescheduler can be used inside a testing/synctest bubble when it is
+// created inside that bubble. Goroutines started by the Rescheduler only block
+// on channels or inside the call function, so they are durably blocked while
+// waiting and sleeps in the call function use the fake clock.
 package rescheduler
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 const (
-	running byte = 0b01
-	rerun   byte = 0b10
+	running uint32 = 0b001
+	rerun   uint32 = 0b010
+	closed  uint32 = 0b100
 )
 
+// ErrWaitInsidePass is returned when waiting for a rescheduler from inside one
+// of its own passes, this would never return as the pass has to finish first.
+var ErrWaitInsidePass = errors.New("rescheduler: wait called inside pass")
+
+// ErrClosed is returned when waiting for a request which will never be served
+// as the rescheduler has been closed.
+var ErrClosed = errors.New("rescheduler: closed")
+
+// PassInfo describes a single run of the call function and the Run() requests
+// which have been coalesced into it.
+type PassInfo struct {
+	// Seq is the sequence number of this pass, starting at 1
+	Seq uint64
+	// Coalesced is the number of Run() requests served by this pass
+	Coalesced int
+	// FirstRequest is the time of the first request served by this pass
+	FirstRequest time.Time
+	// LastRequest is the time of the last request served by this pass
+	LastRequest time.Time
+	// Reasons contains the unique reasons provided to RunReason() in the order
+	// they were first requested
+	Reasons []string
+	// Covers is the highest request sequence served by this pass, see RunSeq()
+	Covers uint64
+	// Weight is the total weight of the requests served by this pass, see
+	// RunWeighted()
+	Weight float64
+}
+
+// CallFunc is the call function run by each pass.
+//
+// The context is the pass context, it must be used when calling WaitFor() or
+// WaitContext() from inside the pass. Calling Run() from inside a pass is
+// allowed and schedules a rerun.
+type CallFunc func(ctx context.Context, info PassInfo) error
+
+// passKey is the context key used to mark a pass context
+type passKey struct{}
+
 // NewRescheduler creates a new rescheduler to run the call function
-func NewRescheduler(call func()) *Rescheduler {
-	return &Rescheduler{
-		lock: &sync.Mutex{},
-		me:   0,
-		call: call,
-		done: makeClosedChannel(),
-	}
+func NewRescheduler(call func(), opts ...Option) *Rescheduler {
+	return NewReschedulerFunc(func(context.Context, PassInfo) error {
+		call()
+		return nil
+	}, opts...)
 }
 
-func makeClosedChannel() chan struct{} {
-	a := make(chan struct{}, 1)
-	close(a)
-	return a
+// NewReschedulerWithInfo creates a new rescheduler to run the call function
+// with information about the pass being run
+func NewReschedulerWithInfo(call func(info PassInfo), opts ...Option) *Rescheduler {
+	return NewReschedulerFunc(func(_ context.Context, info PassInfo) error {
+		call(info)
+		return nil
+	}, opts...)
+}
+
+// NewReschedulerFunc creates a new rescheduler to run the call function with
+// the pass context and information about the pass being run
+func NewReschedulerFunc(call CallFunc, opts ...Option) *Rescheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Rescheduler{
+		ctx:    ctx,
+		cancel: cancel,
+		exited: make(chan struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+	r.settings.Store(newSettings(call, newConfig(opts), nil))
+	return r
 }
 
 // Rescheduler handles the running of synchronous tasks
+//
+// The running and rerun flags are stored in a single atomic value, so
+// requesting a rerun while a pass is running never takes a lock or allocates.
 type Rescheduler struct {
-	lock *sync.Mutex
-	me   byte
-	call func()
-	done chan struct{}
+	// settings holds the call function and options, it is loaded by
+	// threadRun() before each pass
+	settings atomic.Pointer[settings]
+	// settingsLock serialises changes to settings
+	settingsLock sync.Mutex
+	// wake releases a held pass to check if it is allowed to start
+	wake chan struct{}
+	// runNow is the sequence of the last RunNow() request, passes ignore the
+	// time windows until a pass covering it starts
+	runNow atomic.Uint64
+	// runNowServed is the highest request sequence covered by a started pass,
+	// it is only accessed by threadRun()
+	runNowServed uint64
+
+	// ctx is the parent of each pass context, it is cancelled by Close()
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// state holds the running, rerun and closed flags
+	state atomic.Uint32
+	// closeOnce protects the closing of exited
+	closeOnce sync.Once
+	// exited is closed once the rescheduler is closed and threadRun() has
+	// exited
+	exited chan struct{}
+
+	// seq is the sequence number of the last request
+	seq atomic.Uint64
+	// completed is the highest request sequence covered by a finished pass
+	completed atomic.Uint64
+
+	// pending collects the requests for the next pass
+	pendingCount atomic.Int64
+	pendingFirst atomic.Int64
+	pendingLast  atomic.Int64
+	// pendingWeight is the float64 bits of the weight added by RunWeighted()
+	// on top of the weight of 1 for each request
+	pendingWeight atomic.Uint64
+
+	// reasonLock protects pendingReasons
+	reasonLock     sync.Mutex
+	pendingReasons []string
+
+	// history contains the latest passes
+	history passHistory
+
+	// progress is the progress of the running pass
+	progress atomic.Pointer[Progress]
+
+	// invalidated is the sequence of the last request which invalidates the
+	// checkpoint
+	invalidated atomic.Uint64
+	// checkpointLock protects checkpoint and checkpointLoaded
+	checkpointLock   sync.Mutex
+	checkpoint       *checkpointEntry
+	checkpointLoaded bool
+
+	// pass is the sequence number of the last pass started, only one
+	// threadRun() is active at a time so this is not accessed concurrently
+	pass uint64
+	// lastFingerprint is the fingerprint of the last successful pass, it is
+	// only accessed by threadRun()
+	lastFingerprint   string
+	lastFingerprintOk bool
+	// resetFingerprint is set when the settings change so the next pass runs
+	resetFingerprint atomic.Bool
+
+	// counters for Stats()
+	passes   atomic.Uint64
+	failures atomic.Uint64
+	skipped  atomic.Uint64
+
+	// waitLock protects passDone and err
+	waitLock sync.Mutex
+	// passDone is closed after each pass to release WaitFor(), it is only
+	// created when there are waiting goroutines
+	passDone chan struct{}
+	// err is the error returned by the last finished pass
+	err error
 }
 
-// Run starts threadRun() if it isn't running or sets the rerun flag
+// Run starts threadRun() if it isn't running or sets the rerun flag. When
+// called from inside a pass the rerun flag is set, so the call function runs
+// again after the current pass. Run does nothing once the rescheduler is
+// closed.
 func (r *Rescheduler) Run() {
-	r.lock.Lock()
-	// check running state
-	if r.me&running == 1 {
-		// set rerun flag
-		r.me |= rerun
-		r.lock.Unlock()
-		return
+	r.RunReasonSeq("")
+}
+
+// RunReason is the same as Run() but records the reason for the request, the
+// reason is passed to the call function in PassInfo.Reasons
+func (r *Rescheduler) RunReason(reason string) {
+	r.RunReasonSeq(reason)
+}
+
+// RunSeq is the same as Run() but returns the sequence number of the request.
+// The sequence number can be passed to WaitFor() to wait for a pass which
+// started after this request, once the rescheduler is closed WaitFor() returns
+// ErrClosed for new requests.
+func (r *Rescheduler) RunSeq() uint64 {
+	return r.RunReasonSeq("")
+}
+
+// RunReasonSeq is the same as RunReason() but returns the sequence number of
+// the request, see RunSeq()
+func (r *Rescheduler) RunReasonSeq(reason string) uint64 {
+	return r.runSeq(reason, true)
+}
+
+// runSeq requests a pass, the request invalidates the last checkpoint if
+// invalidate is true
+func (r *Rescheduler) runSeq(reason string, invalidate bool) uint64 {
+	// the request still gets a sequence number so WaitFor() returns ErrClosed
+	if r.state.Load()&closed != 0 {
+		return r.seq.Add(1)
+	}
+
+	// the request must be recorded before the flags are changed, otherwise
+	// threadRun() could consume the rerun flag without seeing this request
+	seq := r.addPending(reason, invalidate)
+
+	for {
+		s := r.state.Load()
+
+		// the request is never served if closed
+		if s&closed != 0 {
+			return seq
+		}
+
+		// check running state
+		if s&running != 0 {
+			// set rerun flag
+			if s&rerun != 0 || r.state.CompareAndSwap(s, s|rerun) {
+				return seq
+			}
+			continue
+		}
+
+		// set to running + no rerun
+		if r.state.CompareAndSwap(s, running) {
+			// collect the requests served by the first pass and run background
+			// thread
+			info, ok := r.takePending()
+			go r.threadRun(info, ok)
+			return seq
+		}
 	}
+}
 
-	// set to running + no rerun
-	r.me = running
-	r.done = make(chan struct{}, 1)
-	r.lock.Unlock()
+// addPending records a request for the next pass and returns the sequence
+// number of the request
+func (r *Rescheduler) addPending(reason string, invalidate bool) uint64 {
+	if reason != "" {
+		r.reasonLock.Lock()
+		if !containsString(r.pendingReasons, reason) {
+			r.pendingReasons = append(r.pendingReasons, reason)
+		}
+		r.reasonLock.Unlock()
+	}
 
-	// run background thread
-	go r.threadRun()
+	seq := r.seq.Add(1)
+	now := time.Now().UnixNano()
+	r.pendingFirst.CompareAndSwap(0, now)
+	r.pendingLast.Store(now)
+	r.pendingCount.Add(1)
+
+	cfg := r.settings.Load().cfg
+	if invalidate && cfg.checkpoints != nil {
+		r.invalidateCheckpoint(seq)
+	}
+	if cfg.events != nil {
+		cfg.events(Event{Kind: EventRequest, Time: time.Unix(0, now), Seq: seq, Reason: reason})
+	}
+
+	// wake a pass held for more weight
+	if cfg.weightThreshold > 0 {
+		r.notify()
+	}
+	return seq
 }
 
-// threadRun starts in a goroutine and calls the internal call() field multiple
-// times. After running call() the rerun flag is checked. If it is false then
-// the running flag is cleared, the done channel is closed then reopened to
-// reuse, then breaks out of the loop. If the rerun flag is true then the
-// rerun flag is flipped and the internal call() field gets called again.
-func (r *Rescheduler) threadRun() {
+// storeMax stores v in a if it is higher than the current value
+func storeMax(a *atomic.Uint64, v uint64) {
 	for {
-		// run call
-		r.call()
+		old := a.Load()
+		if v <= old || a.CompareAndSwap(old, v) {
+			return
+		}
+	}
+}
+
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// takePending returns the info for the next pass and resets the pending
+// requests, the sequence number of the pass is set when it starts. The boolean
+// is false if there are no pending requests, this happens when a request was
+// collected by the previous pass before it set the rerun flag.
+func (r *Rescheduler) takePending() (PassInfo, bool) {
+	count := r.pendingCount.Swap(0)
+	if count == 0 {
+		return PassInfo{}, false
+	}
+	first := r.pendingFirst.Swap(0)
+	last := r.pendingLast.Swap(0)
+
+	// the timestamps are updated separately from the count so fill in any
+	// which were collected by the previous pass
+	if first == 0 {
+		first = last
+	}
+	if last == 0 {
+		last = first
+	}
+	weight := float64(count) + math.Float64frombits(r.pendingWeight.Swap(0))
+	if first == 0 {
+		first = time.Now().UnixNano()
+		last = first
+	}
+
+	r.reasonLock.Lock()
+	reasons := r.pendingReasons
+	r.pendingReasons = nil
+	r.reasonLock.Unlock()
+
+	return PassInfo{
+		Coalesced:    int(count),
+		FirstRequest: time.Unix(0, first),
+		LastRequest:  time.Unix(0, last),
+		Reasons:      reasons,
+		Covers:       r.seq.Load(),
+		Weight:       weight,
+	}, true
+}
+
+// mergePending merges the pending requests into info and resets the pending
+// requests
+func (r *Rescheduler) mergePending(info *PassInfo) {
+	more, ok := r.takePending()
+	if !ok {
+		return
+	}
+	info.Coalesced += more.Coalesced
+	info.LastRequest = more.LastRequest
+	for _, reason := range more.Reasons {
+		if !containsString(info.Reasons, reason) {
+			info.Reasons = append(info.Reasons, reason)
+		}
+	}
+	info.Covers = more.Covers
+	info.Weight += more.Weight
+}
+
+// threadRun starts in a goroutine and calls the internal call() field multiple
+// times, starting with the pass described by info if ok is true. Before running
+// call() the pass is held until it is allowed to start. After running call()
+// the closed flag is checked. If it is true then threadRun() exits
+// without running the pending requests. Then the rerun flag is checked. If it
+// is false then the running flag is cleared and the loop is broken. If the
+// rerun flag is true then the rerun flag is flipped, the pending requests are
+// collected and the internal call() field gets called again.
+func (r *Rescheduler) threadRun(info PassInfo, ok bool) {
+	for rerunPass := false; ; rerunPass = true {
+		// wait until the pass is allowed to start then run call
+		if ok {
+			if s := r.hold(&info); s != nil {
+				r.runPass(info, s, rerunPass)
+			}
+		}
+
+		// exit and release Close()
+		if r.state.Load()&closed != 0 {
+			r.state.And(closed)
+			close(r.exited)
+			return
+		}
 
 		// check if a rerun is required and reuse this thread
-		r.lock.Lock()
-		if r.me&rerun == 0 {
-			// clear the run flag
-			r.me = 0
-			// close r.done to release waiting code and make a new done channel
-			close(r.done)
-			r.lock.Unlock()
+		if r.state.CompareAndSwap(running, 0) {
 			break
 		}
-		// flip the rerun flag
-		r.me ^= rerun
-		r.lock.Unlock()
+		// flip the rerun flag and collect the requests served by the next pass
+		r.state.CompareAndSwap(running|rerun, running)
+		info, ok = r.takePending()
 	}
 }
 
-// Wait holds the goroutine until the last call is run (including reruns).
+// hold waits until the pass described by info is allowed to start, requests
+// made while waiting are merged into info. The settings to run the pass with
+// are returned, nil is returned if the rescheduler is closed.
+func (r *Rescheduler) hold(info *PassInfo) *settings {
+	// gatedSince is when the gate first held this pass
+	var gatedSince time.Time
+	for {
+		if r.state.Load()&closed != 0 {
+			return nil
+		}
+
+		// settings are loaded each time so changes apply to held passes
+		s := r.settings.Load()
+
+		// resources are acquired last so they are not held while waiting
+		now := time.Now()
+		override := r.runNow.Load() > r.runNowServed
+		d := s.holdDelay(now, override)
+		if d <= 0 && !override {
+			d = s.cfg.weightDelay(info, now)
+		}
+		if d <= 0 && !override {
+			d = s.cfg.gateDelay(now, &gatedSince)
+		}
+		var changed <-chan struct{}
+		if d <= 0 {
+			if s.claim == nil {
+				r.runNowServed = info.Covers
+				return s
+			}
+			var acquired bool
+			acquired, changed = s.claim.tryAcquire()
+			if acquired {
+				r.runNowServed = info.Covers
+				return s
+			}
+		}
+
+		if !r.wait(d, changed) {
+			return nil
+		}
+		r.mergePending(info)
+	}
+}
+
+// wait waits for d to elapse, ch to be closed or a wakeup from notify(), d is
+// ignored if it is not positive. False is returned if the rescheduler is
+// closed first.
+func (r *Rescheduler) wait(d time.Duration, ch <-chan struct{}) bool {
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-timeout:
+		return true
+	case <-ch:
+		return true
+	case <-r.wake:
+		return true
+	case <-r.ctx.Done():
+		return false
+	}
+}
+
+// notify wakes a held pass without blocking
+func (r *Rescheduler) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runPass runs the call function for the pass described by info with the
+// settings s, the pass is skipped if the fingerprint matches the last
+// successful pass. rerunPass is true if the pass is a rerun of the previous
+// pass.
+func (r *Rescheduler) runPass(info PassInfo, s *settings, rerunPass bool) {
+	if s.claim != nil {
+		defer s.claim.release()
+	}
+	ctx := context.WithValue(r.ctx, passKey{}, r)
+
+	fingerprint, fingerprintOk := s.fingerprint(ctx)
+	if r.resetFingerprint.Swap(false) {
+		r.lastFingerprintOk = false
+	}
+	if fingerprintOk && r.lastFingerprintOk && fingerprint == r.lastFingerprint {
+		r.skipped.Add(1)
+		now := time.Now()
+		r.recordPass(s, info, now, now, nil, rerunPass, true)
+		if r.state.Load()&closed == 0 {
+			r.finishPass(info, nil)
+		}
+		return
+	}
+
+	r.pass++
+	info.Seq = r.pass
+	r.passes.Add(1)
+
+	start := time.Now()
+	progress := newProgress(info, start, s.cfg.events)
+	r.progress.Store(progress)
+	ctx = context.WithValue(ctx, progressKey{}, progress)
+	if s.cfg.checkpoints != nil {
+		ctx = context.WithValue(ctx, checkpointKey{}, r.startCheckpoint(s.cfg.checkpoints, info))
+	}
+	s.emit(Event{Kind: EventPassStart, Time: start, Info: info})
+	err := s.call(ctx, info)
+	r.progress.Store(nil)
+	if s.cfg.checkpoints != nil {
+		r.finishCheckpoint(s.cfg.checkpoints, err)
+	}
+	end := time.Now()
+	s.emit(Event{Kind: EventPassFinish, Time: end, Info: info, Duration: end.Sub(start), Err: err})
+	if err != nil {
+		r.failures.Add(1)
+	}
+	if s.breaker != nil {
+		s.breaker.record(err, time.Now())
+	}
+	if err == nil {
+		r.lastFingerprint, r.lastFingerprintOk = fingerprint, fingerprintOk
+	}
+	r.recordPass(s, info, start, end, err, rerunPass, false)
+
+	// passes cancelled by Close() are not finished
+	if r.state.Load()&closed == 0 {
+		r.finishPass(info, err)
+	}
+}
+
+// finishPass marks the requests covered by info as completed, records the
+// error returned by the pass and releases goroutines in WaitFor()
+func (r *Rescheduler) finishPass(info PassInfo, err error) {
+	r.completed.Store(info.Covers)
+
+	r.waitLock.Lock()
+	r.err = err
+	if r.passDone != nil {
+		close(r.passDone)
+		r.passDone = nil
+	}
+	r.waitLock.Unlock()
+}
+
+// Err returns the error returned by the last finished pass.
+func (r *Rescheduler) Err() error {
+	r.waitLock.Lock()
+	defer r.waitLock.Unlock()
+	return r.err
+}
+
+// Close cancels the context of the running pass, drops any pending requests
+// and releases goroutines in WaitFor() with ErrClosed. Close holds the
+// goroutine until the running pass returns, once Close returns no goroutines
+// started by the rescheduler remain.
+//
+// Close must not be called from inside a pass as it would never return.
+func (r *Rescheduler) Close() error {
+	r.closeOnce.Do(func() {
+		prev := r.state.Or(closed)
+		r.cancel()
+
+		// threadRun() only closes exited if it was running, a new threadRun()
+		// cannot start once the closed flag is set
+		if prev&running == 0 {
+			close(r.exited)
+		}
+	})
+	<-r.exited
+
+	// release waiting goroutines
+	r.waitLock.Lock()
+	if r.passDone != nil {
+		close(r.passDone)
+		r.passDone = nil
+	}
+	r.waitLock.Unlock()
+	return nil
+}
+
+// Wait holds the goroutine until the last call is run (including reruns) or
+// the rescheduler is closed.
+//
+// Wait must not be called from inside a pass as it would never return, use
+// WaitContext() with the pass context instead.
 func (r *Rescheduler) Wait() {
-	<-r.done
+	_ = r.WaitFor(context.Background(), r.seq.Load())
+}
+
+// WaitContext is the same as Wait() but returns the context error if ctx is
+// done first. ErrWaitInsidePass is returned if ctx is a pass context of this
+// rescheduler.
+func (r *Rescheduler) WaitContext(ctx context.Context) error {
+	return r.WaitFor(ctx, r.seq.Load())
+}
+
+// WaitFor holds the goroutine until a pass which started after the request
+// with sequence number seq has finished. The context error is returned if ctx
+// is done first. ErrWaitInsidePass is returned if ctx is a pass context of
+// this rescheduler. ErrClosed is returned if the rescheduler is closed before
+// the request is served.
+func (r *Rescheduler) WaitFor(ctx context.Context, seq uint64) error {
+	if insidePass(ctx, r) {
+		return ErrWaitInsidePass
+	}
+
+	for {
+		if r.completed.Load() >= seq {
+			return nil
+		}
+
+		r.waitLock.Lock()
+		// check again in case the pass finished before the lock was taken
+		if r.completed.Load() >= seq {
+			r.waitLock.Unlock()
+			return nil
+		}
+		if r.state.Load()&closed != 0 {
+			r.waitLock.Unlock()
+			return ErrClosed
+		}
+		if r.passDone == nil {
+			r.passDone = make(chan struct{})
+		}
+		passDone := r.passDone
+		r.waitLock.Unlock()
+
+		select {
+		case <-passDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// insidePass returns true if ctx is a pass context of r
+func insidePass(ctx context.Context, r *Rescheduler) bool {
+	p, _ := ctx.Value(passKey{}).(*Rescheduler)
+	return p == r
 }
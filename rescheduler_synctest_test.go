@@ -0,0 +1,35 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRescheduler_Synctest runs the sleep based tests inside a synctest bubble
+// so the sleeps use a fake clock and finish instantly
+func TestRescheduler_Synctest(t *testing.T) {
+	tests := []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{"SingleRun", TestRescheduler_SingleRun},
+		{"TimeGap", TestRescheduler_TimeGap},
+		{"FinishedBeforeWait", TestRescheduler_FinishedBeforeWait},
+		{"PassInfo", TestRescheduler_PassInfo},
+		{"WaitFor", TestRescheduler_WaitFor},
+		{"WaitInsidePass", TestRescheduler_WaitInsidePass},
+		{"Close", TestRescheduler_Close},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			synctest.Test(t, tt.test)
+			assert.Less(t, time.Since(start), time.Second)
+		})
+	}
+}
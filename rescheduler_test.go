@@ -1,9 +1,13 @@
 package rescheduler
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/mrmelon54/rescheduler/reschedulertest"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRescheduler_SingleRun(t *testing.T) {
@@ -71,13 +75,210 @@ func TestRescheduler_FinishedBeforeWait(t *testing.T) {
 		time.Sleep(time.Millisecond * 100)
 	})
 
-	r.Run()
+	seq := r.RunSeq()
 
 	time.Sleep(time.Millisecond * 200)
 
+	// a cancelled context only returns an error if the pass is unfinished
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if r.WaitFor(ctx, seq) != nil {
+		t.Fatal("Should be finished now")
+	}
+}
+
+func TestRescheduler_PassInfo(t *testing.T) {
+	var infos []PassInfo
+	r := NewReschedulerWithInfo(func(info PassInfo) {
+		time.Sleep(time.Millisecond * 100)
+		infos = append(infos, info)
+	})
+
+	r.RunReason("start")
+	r.RunReason("update")
+	r.Run()
+	r.RunReason("update")
+	r.RunReason("config")
+
+	r.Wait()
+	assert.Len(t, infos, 2)
+	assert.Equal(t, uint64(1), infos[0].Seq)
+	assert.Equal(t, 1, infos[0].Coalesced)
+	assert.Equal(t, []string{"start"}, infos[0].Reasons)
+	assert.Equal(t, uint64(2), infos[1].Seq)
+	assert.Equal(t, 4, infos[1].Coalesced)
+	assert.Equal(t, []string{"update", "config"}, infos[1].Reasons)
+	assert.False(t, infos[1].FirstRequest.After(infos[1].LastRequest))
+}
+
+func TestRescheduler_WaitFor(t *testing.T) {
+	var state, observed atomic.Int32
+	r := NewRescheduler(func() {
+		v := state.Load()
+		time.Sleep(time.Millisecond * 100)
+		observed.Store(v)
+	})
+
+	state.Store(1)
+	seq1 := r.RunSeq()
+	time.Sleep(time.Millisecond * 50)
+	state.Store(2)
+	seq2 := r.RunSeq()
+	assert.Greater(t, seq2, seq1)
+
+	assert.NoError(t, r.WaitFor(context.Background(), seq1))
+	assert.Equal(t, int32(1), observed.Load())
+	assert.NoError(t, r.WaitFor(context.Background(), seq2))
+	assert.Equal(t, int32(2), observed.Load())
+
+	// already completed sequences return immediately
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, r.WaitFor(ctx, seq2))
+
+	// the context error is returned when the pass is still running
+	seq3 := r.RunSeq()
+	assert.ErrorIs(t, r.WaitFor(ctx, seq3), context.Canceled)
+	r.Wait()
+}
+
+func TestRescheduler_Close(t *testing.T) {
+	reschedulertest.VerifyNoLeaks(t)
+	var calls atomic.Int32
+	started := make(chan struct{}, 1)
+	r := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+		calls.Add(1)
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	seq1 := r.RunSeq()
+	seq2 := r.RunSeq()
+	<-started
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- r.WaitFor(context.Background(), seq2)
+	}()
+
+	// Close cancels the running pass and drops the pending rerun
+	assert.NoError(t, r.Close())
+	assert.Equal(t, int32(1), calls.Load())
+	assert.ErrorIs(t, <-waitErr, ErrClosed)
+	assert.ErrorIs(t, r.WaitFor(context.Background(), seq1), ErrClosed)
+
+	// closing again and running after closing do nothing
+	assert.NoError(t, r.Close())
+	r.Run()
+	r.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// requests after closing are never served
+	assert.ErrorIs(t, r.WaitFor(context.Background(), r.RunSeq()), ErrClosed)
+}
+
+func BenchmarkRun_Idle(b *testing.B) {
+	r := NewRescheduler(func() {})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.Run()
+		r.Wait()
+	}
+}
+
+func BenchmarkRun_IdleParallel(b *testing.B) {
+	r := NewRescheduler(func() {})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Run()
+			r.Wait()
+		}
+	})
+}
+
+// blockedRescheduler returns a rescheduler with a running pass, the pass is
+// released when the returned function is called
+func blockedRescheduler() (*Rescheduler, func()) {
+	release := make(chan struct{})
+	r := NewRescheduler(func() { <-release })
+	r.Run()
+	return r, func() {
+		close(release)
+		r.Wait()
+	}
+}
+
+func BenchmarkRun_Coalesced(b *testing.B) {
+	r, release := blockedRescheduler()
+	defer release()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Run()
+	}
+}
+
+func BenchmarkRun_CoalescedParallel(b *testing.B) {
+	r, release := blockedRescheduler()
+	defer release()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Run()
+		}
+	})
+}
+
+func TestRescheduler_RunInsidePass(t *testing.T) {
+	var infos []PassInfo
+	var r *Rescheduler
+	r = NewReschedulerWithInfo(func(info PassInfo) {
+		infos = append(infos, info)
+		if info.Seq == 1 {
+			r.RunReason("inside")
+		}
+	})
+
+	r.Run()
+	r.Wait()
+	assert.Len(t, infos, 2)
+	assert.Equal(t, []string{"inside"}, infos[1].Reasons)
+}
+
+func TestRescheduler_WaitInsidePass(t *testing.T) {
+	var r *Rescheduler
+	r = NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+		if info.Seq == 1 {
+			// waiting for a request made inside the pass
+			return r.WaitFor(ctx, r.RunSeq())
+		}
+		return r.WaitContext(ctx)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		r.Run()
+		r.Wait()
+		close(done)
+	}()
+
 	select {
-	case <-r.done:
-	default:
-		t.Fatal("Should receive from done channel now")
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Wait inside pass should not deadlock")
 	}
+	// both passes return the error
+	assert.ErrorIs(t, r.Err(), ErrWaitInsidePass)
+
+	// waiting with a different context inside the pass of another rescheduler
+	// is allowed
+	other := NewReschedulerFunc(func(ctx context.Context, info PassInfo) error {
+		return r.WaitContext(ctx)
+	})
+	other.Run()
+	other.Wait()
+	assert.NoError(t, other.Err())
 }
@@ -0,0 +1,101 @@
+// Package reschedulertest provides helpers for testing code which uses the
+// rescheduler package.
+package reschedulertest
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reschedulerPrefix matches functions in the rescheduler package but not in
+// its subpackages
+const reschedulerPrefix = "github.com/mrmelon54/rescheduler."
+
+// leakTimeout is how long VerifyNoLeaks waits for goroutines to exit
+const leakTimeout = time.Second
+
+// VerifyNoLeaks checks that no goroutines started by the rescheduler package
+// remain at the end of the test. Goroutines which already exist when
+// VerifyNoLeaks is called are ignored.
+//
+//	func TestService(t *testing.T) {
+//		reschedulertest.VerifyNoLeaks(t)
+//		s := NewService()
+//		defer s.Close()
+//	}
+func VerifyNoLeaks(t testing.TB) {
+	t.Helper()
+	existing := make(map[string]bool)
+	for _, g := range reschedulerGoroutines() {
+		existing[goroutineID(g)] = true
+	}
+
+	t.Cleanup(func() {
+		t.Helper()
+		var leaked []string
+		deadline := time.Now().Add(leakTimeout)
+		for delay := time.Microsecond; ; delay *= 2 {
+			leaked = leaked[:0]
+			for _, g := range reschedulerGoroutines() {
+				if !existing[goroutineID(g)] {
+					leaked = append(leaked, g)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			// goroutines may still be exiting after Close() returns
+			time.Sleep(min(delay, 10*time.Millisecond))
+		}
+		if len(leaked) != 0 {
+			t.Errorf("found %d leaked rescheduler goroutines:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	})
+}
+
+// reschedulerGoroutines returns the stacks of goroutines running functions
+// from non-test files in the rescheduler package, the calling goroutine is
+// skipped
+func reschedulerGoroutines() []string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+
+	var found []string
+	stacks := bytes.Split(buf, []byte("\n\n"))
+	for _, g := range stacks[1:] {
+		if isReschedulerGoroutine(string(g)) {
+			found = append(found, string(g))
+		}
+	}
+	return found
+}
+
+// isReschedulerGoroutine returns true if a frame of the goroutine stack is a
+// function from a non-test file in the rescheduler package
+func isReschedulerGoroutine(stack string) bool {
+	lines := strings.Split(stack, "\n")
+	for i := 1; i+1 < len(lines); i += 2 {
+		fn, file := lines[i], strings.TrimSpace(lines[i+1])
+		if !strings.HasPrefix(fn, reschedulerPrefix) || strings.Contains(file, "_test.go:") {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// goroutineID returns the "goroutine N" prefix of the stack
+func goroutineID(stack string) string {
+	id, _, _ := strings.Cut(stack, " [")
+	return id
+}
@@ -0,0 +1,70 @@
+package reschedulertest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mrmelon54/rescheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeT records errors and runs cleanup functions when finish is called
+type fakeT struct {
+	testing.TB
+	errors  []string
+	cleanup []func()
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Cleanup(fn func()) { f.cleanup = append(f.cleanup, fn) }
+
+func (f *fakeT) Errorf(format string, args ...any) { f.errors = append(f.errors, format) }
+
+func (f *fakeT) finish() {
+	for i := len(f.cleanup) - 1; i >= 0; i-- {
+		f.cleanup[i]()
+	}
+}
+
+func blockingRescheduler() *rescheduler.Rescheduler {
+	return rescheduler.NewReschedulerFunc(func(ctx context.Context, info rescheduler.PassInfo) error {
+		<-ctx.Done()
+		return nil
+	})
+}
+
+func TestVerifyNoLeaks_Closed(t *testing.T) {
+	ft := &fakeT{TB: t}
+	VerifyNoLeaks(ft)
+
+	r := blockingRescheduler()
+	r.Run()
+	assert.NoError(t, r.Close())
+
+	ft.finish()
+	assert.Empty(t, ft.errors)
+}
+
+func TestVerifyNoLeaks_Leaked(t *testing.T) {
+	ft := &fakeT{TB: t}
+	VerifyNoLeaks(ft)
+
+	r := blockingRescheduler()
+	r.Run()
+
+	ft.finish()
+	assert.Len(t, ft.errors, 1)
+	assert.NoError(t, r.Close())
+}
+
+func TestVerifyNoLeaks_Existing(t *testing.T) {
+	r := blockingRescheduler()
+	r.Run()
+	defer r.Close()
+
+	ft := &fakeT{TB: t}
+	VerifyNoLeaks(ft)
+	ft.finish()
+	assert.Empty(t, ft.errors)
+}
@@ -0,0 +1,15 @@
+// Command reschedvet reports common misuse of the rescheduler package. It is
+// in its own module so the rescheduler package does not depend on x/tools.
+//
+//	go install github.com/mrmelon54/rescheduler/reschedvet/cmd/reschedvet@latest
+//	go vet -vettool=$(which reschedvet) ./...
+package main
+
+import (
+	"github.com/mrmelon54/rescheduler/reschedvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(reschedvet.Analyzer)
+}
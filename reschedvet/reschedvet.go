@@ -0,0 +1,248 @@
+// Package reschedvet defines an Analyzer which reports common misuse of the
+// rescheduler package.
+//
+// The following patterns are reported:
+//
+//   - Wait() called inside the call function of the same Rescheduler, which
+//     never returns as the pass has to finish first
+//   - Rescheduler copied by value, which copies the internal state
+//   - zero-value Rescheduler used without NewRescheduler(), which has no call
+//     function to run
+package reschedvet
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const reschedulerPath = "github.com/mrmelon54/rescheduler"
+
+const doc = `check for common misuse of the rescheduler package
+
+Reports Wait() called inside the call function of the same Rescheduler,
+Rescheduler values being copied and zero-value Rescheduler values which are
+not created by NewRescheduler().`
+
+// Analyzer reports common misuse of the rescheduler package
+var Analyzer = &analysis.Analyzer{
+	Name:     "reschedvet",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	// the rescheduler package is allowed to use its own internals
+	if pass.Pkg.Path() == reschedulerPath {
+		return nil, nil
+	}
+
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.CallExpr)(nil),
+		(*ast.CompositeLit)(nil),
+		(*ast.FuncType)(nil),
+		(*ast.RangeStmt)(nil),
+		(*ast.ReturnStmt)(nil),
+		(*ast.StructType)(nil),
+		(*ast.ValueSpec)(nil),
+	}
+	insp.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			checkWaitInsideCall(pass, n)
+			checkCopy(pass, n.Rhs...)
+		case *ast.CallExpr:
+			checkNew(pass, n)
+			if !isBuiltin(pass, n.Fun) {
+				checkCopy(pass, n.Args...)
+			}
+		case *ast.CompositeLit:
+			if isRescheduler(pass.TypesInfo.TypeOf(n)) {
+				pass.Reportf(n.Pos(), "zero-value Rescheduler, use NewRescheduler")
+			}
+		case *ast.FuncType:
+			checkParams(pass, n)
+		case *ast.RangeStmt:
+			if n.Value != nil && isRescheduler(pass.TypesInfo.TypeOf(n.Value)) {
+				pass.Reportf(n.Value.Pos(), "range copies Rescheduler by value")
+			}
+		case *ast.ReturnStmt:
+			checkCopy(pass, n.Results...)
+		case *ast.StructType:
+			checkFields(pass, n)
+		case *ast.ValueSpec:
+			checkCopy(pass, n.Values...)
+			if len(n.Values) == 0 && n.Type != nil && isRescheduler(pass.TypesInfo.TypeOf(n.Type)) {
+				pass.Reportf(n.Pos(), "zero-value Rescheduler, use NewRescheduler")
+			}
+		}
+	})
+	return nil, nil
+}
+
+// isRescheduler returns true if t is the Rescheduler type, pointers are not
+// included
+func isRescheduler(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == reschedulerPath && obj.Name() == "Rescheduler"
+}
+
+func isBuiltin(pass *analysis.Pass, fun ast.Expr) bool {
+	id, ok := ast.Unparen(fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = pass.TypesInfo.Uses[id].(*types.Builtin)
+	return ok
+}
+
+// checkNew reports new(Rescheduler)
+func checkNew(pass *analysis.Pass, call *ast.CallExpr) {
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok || len(call.Args) != 1 || !isBuiltin(pass, id) || id.Name != "new" {
+		return
+	}
+	if isRescheduler(pass.TypesInfo.TypeOf(call.Args[0])) {
+		pass.Reportf(call.Pos(), "zero-value Rescheduler, use NewRescheduler")
+	}
+}
+
+// checkCopy reports expressions which copy an existing Rescheduler value,
+// composite literals are reported as zero-values instead
+func checkCopy(pass *analysis.Pass, exprs ...ast.Expr) {
+	for _, expr := range exprs {
+		switch ast.Unparen(expr).(type) {
+		case *ast.CompositeLit, *ast.CallExpr:
+			continue
+		}
+		if isRescheduler(pass.TypesInfo.TypeOf(expr)) {
+			pass.Reportf(expr.Pos(), "Rescheduler copied by value, use *Rescheduler")
+		}
+	}
+}
+
+// checkFields reports struct fields which hold a zero-value Rescheduler
+func checkFields(pass *analysis.Pass, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		if isRescheduler(pass.TypesInfo.TypeOf(field.Type)) {
+			pass.Reportf(field.Pos(), "zero-value Rescheduler field, use *Rescheduler created by NewRescheduler")
+		}
+	}
+}
+
+// checkParams reports parameters and results which pass a Rescheduler by value
+func checkParams(pass *analysis.Pass, fn *ast.FuncType) {
+	lists := []*ast.FieldList{fn.Params, fn.Results}
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		for _, field := range list.List {
+			if isRescheduler(pass.TypesInfo.TypeOf(field.Type)) {
+				pass.Reportf(field.Pos(), "Rescheduler passed by value, use *Rescheduler")
+			}
+		}
+	}
+}
+
+// checkWaitInsideCall reports Wait() called inside a function literal passed
+// to a constructor when the result is assigned to the Rescheduler being waited
+// on
+func checkWaitInsideCall(pass *analysis.Pass, assign *ast.AssignStmt) {
+	if len(assign.Lhs) != len(assign.Rhs) {
+		return
+	}
+	for i, rhs := range assign.Rhs {
+		call, ok := ast.Unparen(rhs).(*ast.CallExpr)
+		if !ok || !isConstructor(pass, call) {
+			continue
+		}
+		for _, arg := range call.Args {
+			lit, ok := ast.Unparen(arg).(*ast.FuncLit)
+			if !ok {
+				continue
+			}
+			reportWait(pass, lit.Body, assign.Lhs[i])
+		}
+	}
+}
+
+// isConstructor returns true if call is a function in the rescheduler package
+// which returns *Rescheduler
+func isConstructor(pass *analysis.Pass, call *ast.CallExpr) bool {
+	var id *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return false
+	}
+	fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != reschedulerPath {
+		return false
+	}
+	res := fn.Type().(*types.Signature).Results()
+	if res.Len() != 1 {
+		return false
+	}
+	ptr, ok := res.At(0).Type().(*types.Pointer)
+	return ok && isRescheduler(ptr.Elem())
+}
+
+// reportWait reports calls to target.Wait() inside body, function literals
+// started in a new goroutine are skipped as they do not block the pass
+func reportWait(pass *analysis.Pass, body *ast.BlockStmt, target ast.Expr) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GoStmt:
+			return false
+		case *ast.CallExpr:
+			sel, ok := ast.Unparen(n.Fun).(*ast.SelectorExpr)
+			if ok && sel.Sel.Name == "Wait" && sameExpr(pass, sel.X, target) {
+				pass.Reportf(n.Pos(), "Wait called inside the call function of the same Rescheduler will never return")
+			}
+		}
+		return true
+	})
+}
+
+// sameExpr returns true if a and b refer to the same variable or field chain
+func sameExpr(pass *analysis.Pass, a, b ast.Expr) bool {
+	a, b = ast.Unparen(a), ast.Unparen(b)
+	switch a := a.(type) {
+	case *ast.Ident:
+		b, ok := b.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		obj := pass.TypesInfo.ObjectOf(a)
+		return obj != nil && obj == pass.TypesInfo.ObjectOf(b)
+	case *ast.SelectorExpr:
+		b, ok := b.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		obj := pass.TypesInfo.ObjectOf(a.Sel)
+		return obj != nil && obj == pass.TypesInfo.ObjectOf(b.Sel) && sameExpr(pass, a.X, b.X)
+	case *ast.StarExpr:
+		b, ok := b.(*ast.StarExpr)
+		return ok && sameExpr(pass, a.X, b.X)
+	}
+	return false
+}
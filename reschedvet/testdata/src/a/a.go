@@ -0,0 +1,65 @@
+package a
+
+import (
+	"context"
+
+	"github.com/mrmelon54/rescheduler"
+)
+
+type service struct {
+	r    *rescheduler.Rescheduler
+	bad  rescheduler.Rescheduler // want `zero-value Rescheduler field`
+	data []int
+}
+
+func waitInsideVar() {
+	var r *rescheduler.Rescheduler
+	r = rescheduler.NewRescheduler(func() {
+		r.Run()
+		r.Wait() // want `Wait called inside the call function of the same Rescheduler`
+	})
+	r.Run()
+	r.Wait()
+}
+
+func (s *service) waitInsideField() {
+	s.r = rescheduler.NewReschedulerFunc(func(ctx context.Context, info rescheduler.PassInfo) error {
+		s.r.Wait() // want `Wait called inside the call function of the same Rescheduler`
+		go func() {
+			s.r.Wait()
+		}()
+		return s.r.WaitContext(ctx)
+	})
+}
+
+func waitOther(other *rescheduler.Rescheduler) {
+	var r *rescheduler.Rescheduler
+	r = rescheduler.NewRescheduler(func() {
+		other.Wait()
+	})
+	r.Run()
+}
+
+func copies(r *rescheduler.Rescheduler, list []rescheduler.Rescheduler) {
+	a := *r                  // want `Rescheduler copied by value`
+	use(*r)                  // want `Rescheduler copied by value`
+	for _, v := range list { // want `range copies Rescheduler by value`
+		_ = v // want `Rescheduler copied by value`
+	}
+	_ = a // want `Rescheduler copied by value`
+}
+
+func use(r rescheduler.Rescheduler) {} // want `Rescheduler passed by value`
+
+func returns(r *rescheduler.Rescheduler) rescheduler.Rescheduler { // want `Rescheduler passed by value`
+	return *r // want `Rescheduler copied by value`
+}
+
+func zeroValues() {
+	var r rescheduler.Rescheduler     // want `zero-value Rescheduler, use NewRescheduler`
+	a := &rescheduler.Rescheduler{}   // want `zero-value Rescheduler, use NewRescheduler`
+	b := new(rescheduler.Rescheduler) // want `zero-value Rescheduler, use NewRescheduler`
+	r.Run()
+	a.Run()
+	b.Run()
+}
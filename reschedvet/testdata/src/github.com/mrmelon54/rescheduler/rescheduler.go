@@ -0,0 +1,19 @@
+package rescheduler
+
+import "context"
+
+type PassInfo struct{}
+
+type CallFunc func(ctx context.Context, info PassInfo) error
+
+type Rescheduler struct{ call CallFunc }
+
+func NewRescheduler(call func()) *Rescheduler { return nil }
+
+func NewReschedulerFunc(call CallFunc) *Rescheduler { return nil }
+
+func (r *Rescheduler) Run() {}
+
+func (r *Rescheduler) Wait() {}
+
+func (r *Rescheduler) WaitContext(ctx context.Context) error { return nil }
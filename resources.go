@@ -0,0 +1,137 @@
+package rescheduler
+
+import (
+	"slices"
+	"sync"
+)
+
+// DefaultResources is the registry used by WithExclusive() and WithShared()
+// unless WithResources() is used.
+var DefaultResources = NewResources()
+
+// Resources is a registry of named resources shared between reschedulers. A
+// pass holding a resource exclusively never runs at the same time as another
+// pass holding the same resource. Passes holding a resource shared only
+// exclude passes holding it exclusively.
+//
+// All resources of a pass are acquired together under the registry lock or
+// not at all, so a pass never holds some resources while waiting for others
+// and passes waiting for resources cannot deadlock each other.
+//
+// Reschedulers used inside a testing/synctest bubble should use a registry
+// created inside the bubble.
+type Resources struct {
+	lock      sync.Mutex
+	exclusive map[string]bool
+	shared    map[string]int
+	// released is closed when resources are released
+	released chan struct{}
+}
+
+// NewResources creates an empty resource registry
+func NewResources() *Resources {
+	return &Resources{
+		exclusive: make(map[string]bool),
+		shared:    make(map[string]int),
+		released:  make(chan struct{}),
+	}
+}
+
+// WithExclusive holds the named resources exclusively during each pass. The
+// pass is kept pending until the resources are free.
+func WithExclusive(names ...string) Option {
+	return func(c *config) {
+		c.exclusive = append(c.exclusive, names...)
+	}
+}
+
+// WithShared holds the named resources shared during each pass. The pass is
+// kept pending while any of the resources are held exclusively.
+func WithShared(names ...string) Option {
+	return func(c *config) {
+		c.shared = append(c.shared, names...)
+	}
+}
+
+// WithResources sets the registry for WithExclusive() and WithShared(),
+// DefaultResources is used if this option is not set.
+func WithResources(res *Resources) Option {
+	return func(c *config) {
+		c.resources = res
+	}
+}
+
+// resourceClaim is the set of resources required by the passes of a
+// rescheduler
+type resourceClaim struct {
+	res       *Resources
+	exclusive []string
+	shared    []string
+}
+
+// newResourceClaim returns nil if no resources are required, resources which
+// are both exclusive and shared are held exclusively
+func newResourceClaim(res *Resources, exclusive, shared []string) *resourceClaim {
+	if len(exclusive) == 0 && len(shared) == 0 {
+		return nil
+	}
+	if res == nil {
+		res = DefaultResources
+	}
+	exclusive = sortedUnique(exclusive)
+	shared = slices.DeleteFunc(sortedUnique(shared), func(name string) bool {
+		_, found := slices.BinarySearch(exclusive, name)
+		return found
+	})
+	return &resourceClaim{res: res, exclusive: exclusive, shared: shared}
+}
+
+func sortedUnique(names []string) []string {
+	names = slices.Clone(names)
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// tryAcquire acquires all resources of the claim or none of them. If the
+// resources are not acquired the returned channel is closed when resources
+// are next released.
+func (c *resourceClaim) tryAcquire() (bool, <-chan struct{}) {
+	res := c.res
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	for _, name := range c.exclusive {
+		if res.exclusive[name] || res.shared[name] > 0 {
+			return false, res.released
+		}
+	}
+	for _, name := range c.shared {
+		if res.exclusive[name] {
+			return false, res.released
+		}
+	}
+	for _, name := range c.exclusive {
+		res.exclusive[name] = true
+	}
+	for _, name := range c.shared {
+		res.shared[name]++
+	}
+	return true, nil
+}
+
+// release releases all resources of the claim and wakes waiting passes
+func (c *resourceClaim) release() {
+	res := c.res
+	res.lock.Lock()
+	defer res.lock.Unlock()
+	for _, name := range c.exclusive {
+		delete(res.exclusive, name)
+	}
+	for _, name := range c.shared {
+		res.shared[name]--
+		if res.shared[name] == 0 {
+			delete(res.shared, name)
+		}
+	}
+	close(res.released)
+	res.released = make(chan struct{})
+}
@@ -0,0 +1,118 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// overlapTracker records the maximum number of concurrent passes
+type overlapTracker struct {
+	active, max atomic.Int32
+}
+
+func (o *overlapTracker) pass(d time.Duration) func() {
+	return func() {
+		n := o.active.Add(1)
+		for {
+			m := o.max.Load()
+			if n <= m || o.max.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(d)
+		o.active.Add(-1)
+	}
+}
+
+func TestWithExclusive(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		res := NewResources()
+		o := &overlapTracker{}
+		backup := NewRescheduler(o.pass(time.Minute), WithResources(res), WithExclusive("db"))
+		compaction := NewRescheduler(o.pass(time.Minute), WithResources(res), WithExclusive("db", "disk"))
+		other := NewRescheduler(o.pass(time.Minute), WithResources(res), WithExclusive("cache"))
+
+		start := time.Now()
+		backup.Run()
+		synctest.Wait()
+		compaction.Run()
+		compaction.Run()
+		other.Run()
+
+		// other does not share resources so runs at the same time
+		other.Wait()
+		assert.Equal(t, time.Minute, time.Since(start))
+		assert.Equal(t, int32(2), o.max.Load())
+
+		// compaction is kept pending until backup finishes
+		compaction.Wait()
+		assert.Equal(t, 2*time.Minute, time.Since(start))
+		assert.Equal(t, uint64(1), compaction.Stats().Passes)
+	})
+}
+
+func TestWithShared(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		res := NewResources()
+		o := &overlapTracker{}
+		readers := []*Rescheduler{
+			NewRescheduler(o.pass(time.Minute), WithResources(res), WithShared("db")),
+			NewRescheduler(o.pass(time.Minute), WithResources(res), WithShared("db")),
+		}
+		writer := NewRescheduler(o.pass(time.Minute), WithResources(res), WithExclusive("db"), WithShared("db"))
+
+		start := time.Now()
+		for _, r := range readers {
+			r.Run()
+		}
+		synctest.Wait()
+		writer.Run()
+
+		// readers share the resource and the writer waits for both
+		writer.Wait()
+		assert.Equal(t, 2*time.Minute, time.Since(start))
+		assert.Equal(t, int32(2), o.max.Load())
+
+		// readers wait for the writer
+		o.max.Store(0)
+		writer.Run()
+		synctest.Wait()
+		var wg sync.WaitGroup
+		for _, r := range readers {
+			r.Run()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.Wait()
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 4*time.Minute, time.Since(start))
+		assert.Equal(t, int32(2), o.max.Load())
+	})
+}
+
+func TestWithExclusive_Close(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		res := NewResources()
+		a := NewRescheduler(func() { time.Sleep(time.Hour) }, WithResources(res), WithExclusive("db"))
+		b := NewRescheduler(func() {}, WithResources(res), WithExclusive("db"))
+		a.Run()
+		synctest.Wait()
+		b.Run()
+		synctest.Wait()
+
+		// closing a rescheduler waiting for resources does not wait for them
+		start := time.Now()
+		assert.NoError(t, b.Close())
+		assert.Zero(t, time.Since(start))
+		assert.NoError(t, a.Close())
+	})
+}
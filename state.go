@@ -0,0 +1,60 @@
+package rescheduler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// State is a snapshot of the state of a Rescheduler
+type State struct {
+	// Running is true while a pass is held or running
+	Running bool `json:"running"`
+	// Closed is true once Close() has been called
+	Closed bool `json:"closed"`
+	// Pending is the number of requests waiting for the next pass
+	Pending int64 `json:"pending"`
+	// Breaker is the state of the circuit breaker
+	Breaker string `json:"breaker"`
+	// Stats contains the activity counters
+	Stats Stats `json:"stats"`
+	// LastError is the error returned by the last finished pass
+	LastError string `json:"last_error,omitempty"`
+	// Progress is the progress of the running pass, nil if no pass is running
+	Progress *ProgressSnapshot `json:"progress,omitempty"`
+	// History contains the latest passes from oldest to newest
+	History []PassRecord `json:"history,omitempty"`
+}
+
+// State returns a snapshot of the state of the rescheduler
+func (r *Rescheduler) State() State {
+	s := r.state.Load()
+	state := State{
+		Running: s&running != 0,
+		Closed:  s&closed != 0,
+		Pending: r.pendingCount.Load(),
+		Breaker: r.BreakerState().String(),
+		Stats:   r.Stats(),
+		History: r.History(),
+	}
+	if err := r.Err(); err != nil {
+		state.LastError = err.Error()
+	}
+	if p := r.progress.Load(); p != nil {
+		snapshot := p.Snapshot()
+		state.Progress = &snapshot
+	}
+	return state
+}
+
+// Handler returns an admin http.Handler serving State() as JSON
+func (r *Rescheduler) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(r.State())
+	})
+}
@@ -0,0 +1,23 @@
+package rescheduler
+
+// Stats contains counters for the activity of a Rescheduler
+type Stats struct {
+	// Requests is the number of Run() requests
+	Requests uint64 `json:"requests"`
+	// Passes is the number of times the call function has run
+	Passes uint64 `json:"passes"`
+	// Failures is the number of passes which returned an error
+	Failures uint64 `json:"failures"`
+	// Skipped is the number of passes skipped due to an unchanged fingerprint
+	Skipped uint64 `json:"skipped"`
+}
+
+// Stats returns the counters for the activity of the rescheduler
+func (r *Rescheduler) Stats() Stats {
+	return Stats{
+		Requests: r.seq.Load(),
+		Passes:   r.passes.Load(),
+		Failures: r.failures.Load(),
+		Skipped:  r.skipped.Load(),
+	}
+}
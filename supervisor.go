@@ -0,0 +1,183 @@
+package rescheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RestartPolicy decides when a Supervisor restarts its function
+type RestartPolicy int
+
+const (
+	// RestartAlways restarts the function whenever it returns
+	RestartAlways RestartPolicy = iota
+	// RestartOnFailure restarts the function when it returns an error
+	RestartOnFailure
+	// RestartNever does not restart the function, Run() can still restart it
+	RestartNever
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartAlways:
+		return "always"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartNever:
+		return "never"
+	}
+	return "unknown"
+}
+
+// shouldRestart returns true if the policy restarts after the function
+// returned err
+func (p RestartPolicy) shouldRestart(err error) bool {
+	switch p {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+const (
+	// DefaultMinBackoff is used when SupervisorOptions.MinBackoff is not set
+	DefaultMinBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff is used when SupervisorOptions.MaxBackoff is not set
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// SupervisorOptions configures the restarts of a Supervisor
+type SupervisorOptions struct {
+	// Policy decides when the function is restarted
+	Policy RestartPolicy
+	// MinBackoff is the delay before the first restart, the delay doubles for
+	// each consecutive restart up to MaxBackoff. DefaultMinBackoff is used if
+	// it is not positive.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay before a restart, the delay is reset once
+	// the function has run for longer than MaxBackoff. DefaultMaxBackoff is
+	// used if it is not positive, it is raised to MinBackoff if lower.
+	MaxBackoff time.Duration
+}
+
+// Supervisor runs a long-lived function and restarts it according to the
+// restart policy. The function runs as a pass of a Rescheduler, Run() cancels
+// the running function or backoff and restarts it immediately. Multiple calls
+// to Run() while a restart is pending cause a single restart.
+type Supervisor struct {
+	r    *Rescheduler
+	fn   func(ctx context.Context) error
+	opts SupervisorOptions
+
+	lock sync.Mutex
+	// cancel cancels the running function or backoff
+	cancel context.CancelFunc
+}
+
+// NewSupervisor creates a Supervisor for fn, the function is started by
+// Start(). The options are passed to the underlying Rescheduler.
+func NewSupervisor(fn func(ctx context.Context) error, supervisorOpts SupervisorOptions, opts ...Option) *Supervisor {
+	if supervisorOpts.MinBackoff <= 0 {
+		supervisorOpts.MinBackoff = DefaultMinBackoff
+	}
+	if supervisorOpts.MaxBackoff <= 0 {
+		supervisorOpts.MaxBackoff = DefaultMaxBackoff
+	}
+	supervisorOpts.MaxBackoff = max(supervisorOpts.MaxBackoff, supervisorOpts.MinBackoff)
+	s := &Supervisor{fn: fn, opts: supervisorOpts}
+	s.r = NewReschedulerFunc(s.supervise, opts...)
+	return s
+}
+
+// supervise is the call function of the underlying Rescheduler, it returns
+// when a restart is requested, the policy does not restart the function or the
+// rescheduler is closed
+func (s *Supervisor) supervise(ctx context.Context, info PassInfo) error {
+	defer s.setCancel(nil)
+	backoff := s.opts.MinBackoff
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		s.setCancel(cancel)
+		start := time.Now()
+		err := s.fn(runCtx)
+		restart := runCtx.Err() != nil
+		cancel()
+
+		// closed or restart requested by Run()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if restart {
+			return nil
+		}
+		if !s.opts.Policy.shouldRestart(err) {
+			return err
+		}
+
+		if time.Since(start) > s.opts.MaxBackoff {
+			backoff = s.opts.MinBackoff
+		}
+		if !s.backoff(ctx, backoff) {
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, s.opts.MaxBackoff)
+	}
+}
+
+// backoff waits for d before the next restart, false is returned if Run()
+// requested a restart or the rescheduler is closed
+func (s *Supervisor) backoff(ctx context.Context, d time.Duration) bool {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.setCancel(cancel)
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Supervisor) setCancel(cancel context.CancelFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cancel = cancel
+}
+
+// Start starts the function if it is not running
+func (s *Supervisor) Start() {
+	if s.r.state.Load()&running == 0 {
+		s.r.RunReason("start")
+	}
+}
+
+// Run restarts the function now, cancelling the running function or backoff
+func (s *Supervisor) Run() {
+	// take the cancel function first so a pass started by RunReason() is not
+	// cancelled
+	s.lock.Lock()
+	cancel := s.cancel
+	s.lock.Unlock()
+
+	// the rerun flag must be set before cancelling so the pass restarts
+	s.r.RunReason("restart")
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Close stops the function and waits for it to return
+func (s *Supervisor) Close() error {
+	return s.r.Close()
+}
+
+// Rescheduler returns the Rescheduler running the function
+func (s *Supervisor) Rescheduler() *Rescheduler {
+	return s.r
+}
@@ -0,0 +1,157 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// supervisorLog records the start times of a supervised function
+type supervisorLog struct {
+	lock   sync.Mutex
+	starts []time.Duration
+}
+
+func (l *supervisorLog) add(d time.Duration) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.starts = append(l.starts, d)
+	return len(l.starts)
+}
+
+func (l *supervisorLog) get() []time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]time.Duration(nil), l.starts...)
+}
+
+func TestSupervisor_Backoff(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		log := &supervisorLog{}
+		start := time.Now()
+		s := NewSupervisor(func(ctx context.Context) error {
+			n := log.add(time.Since(start))
+			if n == 5 {
+				// run for longer than the max backoff
+				time.Sleep(time.Minute)
+			}
+			return errors.New("exited")
+		}, SupervisorOptions{Policy: RestartOnFailure, MinBackoff: time.Second, MaxBackoff: 4 * time.Second})
+		s.Start()
+
+		time.Sleep(73 * time.Second)
+		assert.NoError(t, s.Close())
+		assert.Equal(t, []time.Duration{
+			0,
+			1 * time.Second,
+			3 * time.Second,
+			7 * time.Second,
+			11 * time.Second,
+			// backoff is reset after the long run
+			72 * time.Second,
+		}, log.get())
+	})
+}
+
+func TestSupervisor_Policy(t *testing.T) {
+	tests := []struct {
+		policy RestartPolicy
+		err    error
+		starts int
+	}{
+		{RestartAlways, nil, 3},
+		{RestartAlways, errors.New("fail"), 3},
+		{RestartOnFailure, nil, 1},
+		{RestartOnFailure, errors.New("fail"), 3},
+		{RestartNever, errors.New("fail"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				log := &supervisorLog{}
+				s := NewSupervisor(func(ctx context.Context) error {
+					log.add(0)
+					return tt.err
+				}, SupervisorOptions{Policy: tt.policy, MinBackoff: time.Second, MaxBackoff: time.Second})
+				s.Start()
+				time.Sleep(2500 * time.Millisecond)
+				assert.NoError(t, s.Close())
+				assert.Len(t, log.get(), tt.starts)
+			})
+		})
+	}
+}
+
+func TestSupervisor_Run(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		log := &supervisorLog{}
+		start := time.Now()
+		s := NewSupervisor(func(ctx context.Context) error {
+			log.add(time.Since(start))
+			<-ctx.Done()
+			return ctx.Err()
+		}, SupervisorOptions{Policy: RestartAlways, MinBackoff: time.Minute, MaxBackoff: time.Minute})
+		defer s.Close()
+		s.Start()
+		s.Start()
+
+		// multiple restarts are coalesced and skip the backoff
+		time.Sleep(time.Second)
+		s.Run()
+		s.Run()
+		s.Run()
+		synctest.Wait()
+		assert.Equal(t, []time.Duration{0, time.Second}, log.get())
+
+		// closing stops the function without restarting it
+		time.Sleep(time.Second)
+		assert.NoError(t, s.Close())
+		assert.Len(t, log.get(), 2)
+	})
+}
+
+func TestSupervisor_RunAfterStop(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		log := &supervisorLog{}
+		start := time.Now()
+		s := NewSupervisor(func(ctx context.Context) error {
+			log.add(time.Since(start))
+			return nil
+		}, SupervisorOptions{Policy: RestartNever})
+		defer s.Close()
+		s.Start()
+
+		// the function is not restarted by the policy
+		time.Sleep(time.Minute)
+		assert.Equal(t, []time.Duration{0}, log.get())
+
+		// restarting a stopped function starts it again
+		s.Run()
+		synctest.Wait()
+		assert.Equal(t, []time.Duration{0, time.Minute}, log.get())
+	})
+}
+
+func TestSupervisor_DefaultBackoff(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		log := &supervisorLog{}
+		start := time.Now()
+		s := NewSupervisor(func(ctx context.Context) error {
+			log.add(time.Since(start))
+			return nil
+		}, SupervisorOptions{})
+		s.Start()
+
+		// restarts of a function returning immediately back off by default
+		time.Sleep(time.Second)
+		assert.NoError(t, s.Close())
+		assert.Equal(t, []time.Duration{0, 100 * time.Millisecond, 300 * time.Millisecond, 700 * time.Millisecond}, log.get())
+	})
+}
@@ -0,0 +1,46 @@
+package rescheduler
+
+import (
+	"math"
+	"time"
+)
+
+// WithWeightThreshold holds each pass until the total weight of its requests
+// reaches threshold or maxDelay has elapsed since the first request, see
+// RunWeighted(). Requests made by Run() have a weight of 1.
+func WithWeightThreshold(threshold float64, maxDelay time.Duration) Option {
+	return func(c *config) {
+		c.weightThreshold = threshold
+		c.weightMaxDelay = maxDelay
+	}
+}
+
+// RunWeighted is the same as Run() but the request has a weight of w, see
+// WithWeightThreshold()
+func (r *Rescheduler) RunWeighted(w float64) {
+	if r.state.Load()&closed != 0 {
+		return
+	}
+	// the extra weight is recorded first so it is taken with the request
+	r.addWeight(w - 1)
+	r.RunReasonSeq("")
+}
+
+// addWeight adds w to the pending extra weight
+func (r *Rescheduler) addWeight(w float64) {
+	for {
+		old := r.pendingWeight.Load()
+		if r.pendingWeight.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+w)) {
+			return
+		}
+	}
+}
+
+// weightDelay returns how long the pass described by info must wait for more
+// weight
+func (cfg *config) weightDelay(info *PassInfo, now time.Time) time.Duration {
+	if cfg.weightThreshold <= 0 || info.Weight >= cfg.weightThreshold {
+		return 0
+	}
+	return cfg.weightMaxDelay - now.Sub(info.FirstRequest)
+}
@@ -0,0 +1,60 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWeighted(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var lock sync.Mutex
+		var infos []PassInfo
+		passes := func() []PassInfo {
+			lock.Lock()
+			defer lock.Unlock()
+			return infos
+		}
+		r := NewReschedulerWithInfo(func(info PassInfo) {
+			lock.Lock()
+			infos = append(infos, info)
+			lock.Unlock()
+		}, WithWeightThreshold(100, time.Minute))
+
+		// light requests are held until the threshold is reached
+		start := time.Now()
+		r.RunWeighted(10)
+		r.Run()
+		time.Sleep(time.Second)
+		synctest.Wait()
+		assert.Empty(t, passes())
+		r.RunWeighted(89)
+		r.Wait()
+		assert.Equal(t, time.Second, time.Since(start))
+		if assert.Len(t, passes(), 1) {
+			assert.Equal(t, 3, passes()[0].Coalesced)
+			assert.Equal(t, 100.0, passes()[0].Weight)
+		}
+
+		// the pass starts after the maximum delay if the threshold is not reached
+		start = time.Now()
+		r.RunWeighted(5)
+		r.Wait()
+		assert.Equal(t, time.Minute, time.Since(start))
+		if assert.Len(t, passes(), 2) {
+			assert.Equal(t, 5.0, passes()[1].Weight)
+		}
+
+		// heavy requests start immediately
+		start = time.Now()
+		r.RunWeighted(1000)
+		r.Wait()
+		assert.Zero(t, time.Since(start))
+		assert.Len(t, passes(), 3)
+	})
+}
@@ -0,0 +1,155 @@
+package rescheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is a daily range of time in a location. A window with End before
+// Start wraps past midnight, a window with equal Start and End covers the
+// whole day.
+type Window struct {
+	// Start is the offset from midnight when the window opens
+	Start time.Duration
+	// End is the offset from midnight when the window closes
+	End time.Duration
+	// Location is the time zone of the window, UTC is used if nil
+	Location *time.Location
+}
+
+// ParseWindow parses a window in the format "22:00-06:00"
+func ParseWindow(s string, loc *time.Location) (Window, error) {
+	var sh, sm, eh, em int
+	if _, err := fmt.Sscanf(s, "%d:%d-%d:%d", &sh, &sm, &eh, &em); err != nil {
+		return Window{}, fmt.Errorf("rescheduler: invalid window %q: %w", s, err)
+	}
+	if sh < 0 || sh > 23 || eh < 0 || eh > 23 || sm < 0 || sm > 59 || em < 0 || em > 59 {
+		return Window{}, fmt.Errorf("rescheduler: invalid window %q", s)
+	}
+	return Window{
+		Start:    time.Duration(sh)*time.Hour + time.Duration(sm)*time.Minute,
+		End:      time.Duration(eh)*time.Hour + time.Duration(em)*time.Minute,
+		Location: loc,
+	}, nil
+}
+
+// MustParseWindow is the same as ParseWindow but panics on error
+func MustParseWindow(s string, loc *time.Location) Window {
+	w, err := ParseWindow(s, loc)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+func (w Window) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+	return w.Location
+}
+
+// clock returns the offset of t from midnight in the window location
+func (w Window) clock(t time.Time) time.Duration {
+	h, m, s := t.In(w.location()).Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// contains returns true if t is inside the window
+func (w Window) contains(t time.Time) bool {
+	c := w.clock(t)
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return w.Start <= c && c < w.End
+	default:
+		return c >= w.Start || c < w.End
+	}
+}
+
+// next returns the first time after t when the clock in the window location
+// reaches offset
+func (w Window) next(t time.Time, offset time.Duration) time.Time {
+	lt := t.In(w.location())
+	y, mo, d := lt.Date()
+	h, mi, s := int(offset/time.Hour), int(offset%time.Hour/time.Minute), int(offset%time.Minute/time.Second)
+	next := time.Date(y, mo, d, h, mi, s, 0, w.location())
+	if !next.After(t) {
+		next = time.Date(y, mo, d+1, h, mi, s, 0, w.location())
+	}
+	return next
+}
+
+// WithAllowedWindows only starts passes inside one of the windows. Requests
+// outside the windows are kept pending and a single pass starts when the next
+// window opens, see RunNow() to override the windows.
+func WithAllowedWindows(windows ...Window) Option {
+	return func(c *config) {
+		c.allowed = append(c.allowed, windows...)
+	}
+}
+
+// WithBlackoutWindows never starts passes inside the windows. Requests inside
+// a window are kept pending and a single pass starts when the window closes,
+// see RunNow() to override the windows.
+func WithBlackoutWindows(windows ...Window) Option {
+	return func(c *config) {
+		c.blackout = append(c.blackout, windows...)
+	}
+}
+
+// windowSearchLimit limits the number of window edges checked when looking
+// for the next allowed time
+const windowSearchLimit = 64
+
+// windowDelay returns how long until passes are allowed by the windows in cfg
+func (cfg *config) windowDelay(now time.Time) time.Duration {
+	if len(cfg.allowed) == 0 && len(cfg.blackout) == 0 {
+		return 0
+	}
+
+	t := now
+search:
+	for i := 0; i < windowSearchLimit; i++ {
+		// move to the end of a blackout window
+		for _, w := range cfg.blackout {
+			if w.contains(t) {
+				if w.Start == w.End {
+					break search
+				}
+				t = w.next(t, w.End)
+				continue search
+			}
+		}
+
+		if len(cfg.allowed) == 0 {
+			return t.Sub(now)
+		}
+
+		// move to the start of the next allowed window
+		var next time.Time
+		for _, w := range cfg.allowed {
+			if w.contains(t) {
+				return t.Sub(now)
+			}
+			if start := w.next(t, w.Start); next.IsZero() || start.Before(next) {
+				next = start
+			}
+		}
+		t = next
+	}
+
+	// the windows never allow a pass, check again later in case the windows
+	// are reconfigured
+	return 24 * time.Hour
+}
+
+// RunNow is the same as RunReason() but the pass serving the request ignores
+// the allowed and blackout windows, the weight threshold and the gate, it is
+// intended for emergencies.
+func (r *Rescheduler) RunNow(reason string) {
+	seq := r.RunReasonSeq(reason)
+	storeMax(&r.runNow, seq)
+	r.notify()
+}
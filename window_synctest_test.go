@@ -0,0 +1,95 @@
+//go:build go1.25
+
+package rescheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAllowedWindows(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		// the bubble starts at midnight UTC
+		var passes atomic.Int32
+		var coalesced atomic.Int32
+		r := NewReschedulerWithInfo(func(info PassInfo) {
+			passes.Add(1)
+			coalesced.Store(int32(info.Coalesced))
+		}, WithAllowedWindows(MustParseWindow("02:00-04:00", time.UTC)))
+
+		start := time.Now()
+		r.Run()
+		time.Sleep(time.Hour)
+		r.Run()
+		r.Run()
+		synctest.Wait()
+		assert.Zero(t, passes.Load())
+
+		// a single pass starts when the window opens
+		r.Wait()
+		assert.Equal(t, 2*time.Hour, time.Since(start))
+		assert.Equal(t, int32(1), passes.Load())
+		assert.Equal(t, int32(3), coalesced.Load())
+
+		// passes inside the window start immediately
+		r.Run()
+		r.Wait()
+		assert.Equal(t, 2*time.Hour, time.Since(start))
+		assert.Equal(t, int32(2), passes.Load())
+	})
+}
+
+func TestRunNow(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var passes atomic.Int32
+		r := NewRescheduler(func() {
+			passes.Add(1)
+		}, WithBlackoutWindows(MustParseWindow("00:00-06:00", time.UTC)))
+
+		start := time.Now()
+		r.Run()
+		synctest.Wait()
+		assert.Zero(t, passes.Load())
+
+		// the held pass starts immediately
+		r.RunNow("emergency")
+		r.Wait()
+		assert.Zero(t, time.Since(start))
+		assert.Equal(t, int32(1), passes.Load())
+
+		// the override only applies to a single pass
+		r.Run()
+		r.Wait()
+		assert.Equal(t, 6*time.Hour, time.Since(start))
+		assert.Equal(t, int32(2), passes.Load())
+	})
+}
+
+func TestRunNow_DuringPass(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var passes atomic.Int32
+		r := NewRescheduler(func() {
+			passes.Add(1)
+			time.Sleep(time.Minute)
+		}, WithBlackoutWindows(MustParseWindow("00:00-06:00", time.UTC)))
+
+		// an override requested during a pass applies to the rerun
+		start := time.Now()
+		r.RunNow("first")
+		synctest.Wait()
+		r.RunNow("second")
+		r.Wait()
+		assert.Equal(t, 2*time.Minute, time.Since(start))
+		assert.Equal(t, int32(2), passes.Load())
+
+		// the override was used by the rerun
+		r.Run()
+		r.Wait()
+		assert.Equal(t, 6*time.Hour+time.Minute, time.Since(start))
+		assert.Equal(t, int32(3), passes.Load())
+	})
+}
@@ -0,0 +1,53 @@
+package rescheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("22:00-06:30", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, Window{Start: 22 * time.Hour, End: 6*time.Hour + 30*time.Minute, Location: time.UTC}, w)
+
+	_, err = ParseWindow("22:00", time.UTC)
+	assert.Error(t, err)
+	_, err = ParseWindow("24:00-06:00", time.UTC)
+	assert.Error(t, err)
+}
+
+func TestWindow_Delay(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skip("missing time zone data")
+	}
+	at := func(s string) time.Time {
+		t, _ := time.ParseInLocation(time.DateTime, s, london)
+		return t
+	}
+	night := MustParseWindow("22:00-06:00", london)
+	lunch := MustParseWindow("12:00-13:00", london)
+
+	for _, tt := range []struct {
+		name string
+		cfg  config
+		now  string
+		want time.Duration
+	}{
+		{"no windows", config{}, "2026-06-01 15:00:00", 0},
+		{"inside allowed", config{allowed: []Window{night}}, "2026-06-01 23:00:00", 0},
+		{"after midnight", config{allowed: []Window{night}}, "2026-06-01 05:59:00", 0},
+		{"before allowed", config{allowed: []Window{night}}, "2026-06-01 21:30:00", 30 * time.Minute},
+		{"inside blackout", config{blackout: []Window{lunch}}, "2026-06-01 12:15:00", 45 * time.Minute},
+		{"outside blackout", config{blackout: []Window{lunch}}, "2026-06-01 13:00:00", 0},
+		{"blackout in allowed", config{allowed: []Window{night}, blackout: []Window{MustParseWindow("21:00-23:00", london)}}, "2026-06-01 20:00:00", 3 * time.Hour},
+		{"daylight saving", config{allowed: []Window{MustParseWindow("03:00-04:00", london)}}, "2026-03-29 00:00:00", 2 * time.Hour},
+		{"never allowed", config{blackout: []Window{{}}}, "2026-06-01 15:00:00", 24 * time.Hour},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.windowDelay(at(tt.now)))
+		})
+	}
+}